@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryAliases maps a lowercased single-word alias to the query it expands to,
+// loaded from QUERY_ALIASES_FILE
+var queryAliases = map[string]string{}
+
+// QueryAlias represents a shortcut expanded inside food queries
+type QueryAlias struct {
+	Alias string `json:"alias" example:"bfast"`
+	Query string `json:"query" example:"2 eggs and 1 slice whole wheat toast"`
+}
+
+// loadQueryAliases reads a JSON object of alias to query; aliases must be single words
+func loadQueryAliases(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var table map[string]string
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("invalid query aliases file: %w", err)
+	}
+	for alias, query := range table {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias == "" || strings.ContainsAny(alias, " \t\n") {
+			return fmt.Errorf("invalid alias %q, expected a single word", alias)
+		}
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("alias %q has an empty query", alias)
+		}
+		queryAliases[alias] = strings.TrimSpace(query)
+	}
+	return nil
+}
+
+// expandAliases replaces every word of query that is a known alias with its
+// expansion; other words, and queries without aliases, are left untouched
+func expandAliases(query string) string {
+	if len(queryAliases) == 0 {
+		return query
+	}
+
+	words := strings.Fields(query)
+	expanded := false
+	for i, word := range words {
+		if full, ok := queryAliases[strings.ToLower(word)]; ok {
+			words[i] = full
+			expanded = true
+		}
+	}
+	if !expanded {
+		return query
+	}
+	return strings.Join(words, " ")
+}
+
+// GetAliases godoc
+// @Summary List query aliases
+// @Description List the configured shortcuts that are expanded inside food queries before calling Nutritionix
+// @Tags aliases
+// @Produce json
+// @Success 200 {array} QueryAlias
+// @Router /aliases [get]
+func (h *Handler) getAliases(c *gin.Context) {
+	result := make([]QueryAlias, 0, len(queryAliases))
+	for alias, query := range queryAliases {
+		result = append(result, QueryAlias{Alias: alias, Query: query})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Alias < result[j].Alias })
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+)
+
+// commonAllergens maps an allergen tag to the keywords that reveal it in
+// food names, Nutritionix tag items and ingredient statements
+var commonAllergens = map[string][]string{
+	"peanut":    {"peanut"},
+	"tree_nut":  {"almond", "cashew", "walnut", "pecan", "hazelnut", "pistachio", "macadamia"},
+	"milk":      {"milk", "cheese", "cream", "yogurt", "whey", "casein"},
+	"egg":       {"egg"},
+	"soy":       {"soy", "tofu", "tempeh", "edamame"},
+	"wheat":     {"wheat", "bread", "toast", "pasta", "noodle", "flour"},
+	"fish":      {"fish", "salmon", "tuna", "cod", "anchovy"},
+	"shellfish": {"shrimp", "prawn", "crab", "lobster", "clam", "oyster", "mussel"},
+	"sesame":    {"sesame", "tahini"},
+}
+
+// detectAllergens fills AllergenTags on each food from the upstream data
+func detectAllergens(foods []Food) {
+	for i := range foods {
+		text := allergenText(foods[i])
+		var tags []string
+		for tag, keywords := range commonAllergens {
+			for _, kw := range keywords {
+				if strings.Contains(text, kw) {
+					tags = append(tags, tag)
+					break
+				}
+			}
+		}
+		sort.Strings(tags)
+		foods[i].AllergenTags = tags
+	}
+}
+
+// allergenText is the lowercased text searched for allergen keywords
+func allergenText(f Food) string {
+	return strings.ToLower(f.FoodName + " " + f.Tags.Item + " " + f.IngredientStatement)
+}
+
+// containsAllergen reports whether any food in the entry matches the keyword,
+// either through a detected tag or directly in its ingredients
+func containsAllergen(entry Entry, keyword string) bool {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	for _, food := range entry.Nutrients.Foods {
+		for _, tag := range food.AllergenTags {
+			if tag == keyword {
+				return true
+			}
+		}
+		if strings.Contains(allergenText(food), keyword) {
+			return true
+		}
+	}
+	return false
+}
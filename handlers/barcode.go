@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+)
+
+// upcPattern matches UPC/EAN barcodes: UPC-E (8) up to GTIN-14
+var upcPattern = regexp.MustCompile(`^[0-9]{8,14}$`)
+
+// errBarcodeNotFound is returned when Nutritionix has no item for a barcode
+var errBarcodeNotFound = errors.New("no food found for barcode")
+
+// BarcodeEntryRequest represents the entry data for logging a scanned barcode
+type BarcodeEntryRequest struct {
+	Date string `json:"date" binding:"required" example:"2025-08-11" format:"date"`
+	Meal string `json:"meal" binding:"omitempty,oneof=breakfast lunch dinner snack" example:"snack" enums:"breakfast,lunch,dinner,snack"`
+	// Servings multiplies every food of the item; defaults to 1
+	Servings *float64 `json:"servings" binding:"omitempty,gt=0" example:"2"`
+}
+
+// lookupBarcode fetches the packaged food for a UPC from Nutritionix
+func (h *Handler) lookupBarcode(ctx context.Context, upc string) (NutritionixResponse, string, error) {
+	items, err := h.nutritionix.Item(ctx, upc)
+	if errors.Is(err, nutritionix.ErrNotFound) || err == nil && len(items) == 0 {
+		return NutritionixResponse{}, "", errBarcodeNotFound
+	}
+	if err != nil {
+		return NutritionixResponse{}, "", err
+	}
+
+	nutrients := NutritionixResponse{Foods: make([]Food, len(items))}
+	for i, item := range items {
+		nutrients.Foods[i] = item.Food
+	}
+	detectAllergens(nutrients.Foods)
+
+	first := items[0]
+	name := strings.TrimSpace(first.BrandName + " " + first.FoodName)
+	return nutrients, name, nil
+}
+
+// CreateEntryFromBarcode godoc
+// @Summary Log a packaged food by barcode
+// @Description Look up a UPC/EAN barcode with Nutritionix and store the item as a normal entry; the entry query is the brand and food name, and the item goes through the same food limit, zero-calorie check and servings scaling as POST /entries
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param upc path string true "Barcode, 8 to 14 digits"
+// @Param entry body BarcodeEntryRequest true "Entry data"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 201 {object} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/barcode/{upc} [post]
+func (h *Handler) createEntryFromBarcode(c *gin.Context) {
+	upc := c.Param("upc")
+	if !upcPattern.MatchString(upc) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "upc must be 8 to 14 digits"})
+		return
+	}
+	var req BarcodeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nutrients, name, err := h.lookupBarcode(c.Request.Context(), upc)
+	countNutritionixCall(err)
+	if errors.Is(err, errBarcodeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No food found for barcode"})
+		return
+	}
+	if err != nil {
+		logNutritionixError(c.Request.Context(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch nutrition data"})
+		return
+	}
+
+	truncated, err := checkFoods(name, &nutrients)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := newEntry(CreateEntryRequest{Query: name, Date: req.Date, Meal: req.Meal, Servings: req.Servings}, nutrients)
+	entry.UserID = userIDFrom(c)
+	entry.Truncated = truncated
+	entry.Source = sourceBarcode
+	created, err := h.insertEntries([]Entry{entry})
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	respond(c, http.StatusCreated, created[0])
+}
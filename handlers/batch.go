@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBatchSize bounds the Nutritionix fan-out of a single batch
+const maxBatchSize = 25
+
+// BatchItemResult represents the outcome of one item of a batch
+type BatchItemResult struct {
+	Index  int    `json:"index" example:"0"`
+	Status int    `json:"status" example:"201"`
+	Entry  *Entry `json:"entry,omitempty"`
+	Error  string `json:"error,omitempty" example:"Failed to fetch nutrition data"`
+}
+
+// BatchResponse represents a partially successful batch
+type BatchResponse struct {
+	Results []BatchItemResult `json:"results"`
+}
+
+// CreateEntriesBatch godoc
+// @Summary Create multiple entries
+// @Description Create every item independently; returns 201 with the entries in input order when all succeed, otherwise 207 with a per-item status so one bad query does not discard the rest. The body is decoded strictly: unknown fields and malformed items reject the whole batch with 400
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param entries body []CreateEntryRequest true "Entries to create"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 201 {array} Entry
+// @Success 207 {object} BatchResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse "Body larger than 64 KB"
+// @Router /entries/batch [post]
+func (h *Handler) createEntriesBatch(c *gin.Context) {
+	var reqs []CreateEntryRequest
+	if status, err := bindStrictJSON(c, &reqs); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Batch must contain between 1 and %d entries", maxBatchSize)})
+		return
+	}
+
+	results := make([]BatchItemResult, len(reqs))
+	failed := false
+	for i, req := range reqs {
+		entry, status, err := h.createBatchItem(c, req)
+		results[i] = BatchItemResult{Index: i, Status: status}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed = true
+			continue
+		}
+		results[i].Entry = &entry
+	}
+
+	if failed {
+		respond(c, http.StatusMultiStatus, BatchResponse{Results: results})
+		return
+	}
+	created := make([]Entry, len(results))
+	for i, result := range results {
+		created[i] = *result.Entry
+	}
+	respond(c, http.StatusCreated, created)
+}
+
+// createBatchItem fetches and stores a single batch item, returning the
+// status it would have had as its own POST /entries
+func (h *Handler) createBatchItem(c *gin.Context, req CreateEntryRequest) (Entry, int, error) {
+	req.Query = expandAliases(req.Query)
+	if req.ClientID != "" && h.clientIDExists(userIDFrom(c), req.ClientID) {
+		return Entry{}, http.StatusConflict, errDuplicateClientID
+	}
+
+	nutrients, truncated, status, err := h.entryNutrients(c.Request.Context(), req.Query)
+	if err != nil {
+		return Entry{}, status, err
+	}
+
+	entry := newEntry(req, nutrients)
+	entry.UserID = userIDFrom(c)
+	entry.Truncated = truncated
+	created, err := h.insertEntries([]Entry{entry})
+	if errors.Is(err, errDuplicateClientID) {
+		return Entry{}, http.StatusConflict, err
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		return Entry{}, http.StatusInternalServerError, errors.New("Failed to save entry")
+	}
+	return created[0], http.StatusCreated, nil
+}
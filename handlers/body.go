@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxRequestBodyBytes bounds the JSON body of a create request
+const maxRequestBodyBytes = 64 << 10
+
+// errBodyTooLarge is returned when a request body exceeds maxRequestBodyBytes
+var errBodyTooLarge = fmt.Errorf("request body too large, limit is %d bytes", maxRequestBodyBytes)
+
+// limitRequestBody caps the request body at maxRequestBodyBytes; it must run
+// before anything that reads the body
+func limitRequestBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodyBytes)
+		c.Next()
+	}
+}
+
+// bindStrictJSON decodes a single JSON object into obj, rejecting unknown
+// fields, and validates its binding tags; the returned status tells an
+// oversized body (413) from a malformed or invalid one (400)
+func bindStrictJSON(c *gin.Context, obj interface{}) (int, error) {
+	dec := json.NewDecoder(c.Request.Body)
+	dec.DisallowUnknownFields()
+
+	var tooLarge *http.MaxBytesError
+	err := dec.Decode(obj)
+	if err == nil {
+		// Only whitespace may follow the object; reading up to EOF also
+		// catches trailing whitespace past the limit
+		if _, err = dec.Token(); errors.Is(err, io.EOF) {
+			err = nil
+		} else if !errors.As(err, &tooLarge) {
+			err = errors.New("unexpected data after the JSON object")
+		}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, errBodyTooLarge
+	case errors.Is(err, io.EOF):
+		return http.StatusBadRequest, errors.New("malformed JSON: request body is empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return http.StatusBadRequest, fmt.Errorf("unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	case errors.As(err, &typeErr):
+		return http.StatusBadRequest, fmt.Errorf("malformed JSON: field %q must be %s", typeErr.Field, typeErr.Type)
+	default:
+		return http.StatusBadRequest, fmt.Errorf("malformed JSON: %s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCreateEntryStrictBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  int
+		wantError string
+	}{
+		{
+			name:      "oversized",
+			body:      `{"query":"` + strings.Repeat("a", maxRequestBodyBytes) + `","date":"2025-08-11"}`,
+			wantCode:  http.StatusRequestEntityTooLarge,
+			wantError: errBodyTooLarge.Error(),
+		},
+		{
+			name:      "oversized by trailing whitespace",
+			body:      `{"query":"apple","date":"2025-08-11"}` + strings.Repeat(" ", maxRequestBodyBytes),
+			wantCode:  http.StatusRequestEntityTooLarge,
+			wantError: errBodyTooLarge.Error(),
+		},
+		{
+			name:      "unknown field",
+			body:      `{"query":"apple","date":"2025-08-11","calories":100}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: `unknown field "calories"`,
+		},
+		{
+			name:      "malformed",
+			body:      `{"query":"apple",`,
+			wantCode:  http.StatusBadRequest,
+			wantError: "malformed JSON",
+		},
+		{
+			name:      "wrong type",
+			body:      `{"query":"apple","date":"2025-08-11","mood":"good"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: `malformed JSON: field "mood" must be int`,
+		},
+		{
+			name:      "empty",
+			body:      ``,
+			wantCode:  http.StatusBadRequest,
+			wantError: "malformed JSON: request body is empty",
+		},
+		{
+			name:      "trailing data",
+			body:      `{"query":"apple","date":"2025-08-11"}{}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: "unexpected data after the JSON object",
+		},
+		{
+			name:     "valid",
+			body:     `{"query":"apple","date":"2025-08-11"}`,
+			wantCode: http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _, client := newTestRouter(t)
+
+			w := serve(r, http.MethodPost, "/entries", "alice", tt.body)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantError == "" {
+				return
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(resp.Error, tt.wantError) {
+				t.Errorf("error = %q, want it to contain %q", resp.Error, tt.wantError)
+			}
+			if client.calls != 0 {
+				t.Errorf("Nutritionix was called %d times for a rejected body", client.calls)
+			}
+		})
+	}
+}
+
+func TestBatchRestrictsCreateFields(t *testing.T) {
+	defer func(allowed map[string]bool, strict bool) {
+		allowedCreateFields, createFieldsStrict = allowed, strict
+	}(allowedCreateFields, createFieldsStrict)
+	allowedCreateFields = map[string]bool{"meal": true}
+	body := `[{"query":"1 apple","date":"2025-08-11","meal":"lunch"},{"query":"1 egg","date":"2025-08-11","mood":5}]`
+
+	createFieldsStrict = true
+	r, s, _ := newTestRouter(t)
+	w := serve(r, http.MethodPost, "/entries/batch", "alice", body)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "Fields not allowed: mood") {
+		t.Errorf("strict batch = %d %s, want 400 naming mood", w.Code, w.Body)
+	}
+	if n := s.Count(); n != 0 {
+		t.Errorf("stored %d entries, want 0", n)
+	}
+
+	createFieldsStrict = false
+	r, s, _ = newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries/batch", "alice", body); w.Code != http.StatusCreated {
+		t.Fatalf("lenient batch status = %d: %s", w.Code, w.Body)
+	}
+	if apple, _ := s.Get(1); apple.Meal != MealLunch {
+		t.Errorf("allowed meal = %q, want %q", apple.Meal, MealLunch)
+	}
+	if egg, _ := s.Get(2); egg.Mood != 0 {
+		t.Errorf("disallowed mood was stored as %d", egg.Mood)
+	}
+}
+
+func TestBatchStrictBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"oversized", `[{"query":"` + strings.Repeat("a", maxRequestBodyBytes) + `","date":"2025-08-11"}]`, http.StatusRequestEntityTooLarge},
+		{"unknown field", `[{"query":"apple","date":"2025-08-11"},{"query":"egg","date":"2025-08-11","calories":5}]`, http.StatusBadRequest},
+		{"trailing data", `[{"query":"apple","date":"2025-08-11"}]{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, s, client := newTestRouter(t)
+			if w := serve(r, http.MethodPost, "/entries/batch", "alice", tt.body); w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if s.Count() != 0 || client.calls != 0 {
+				t.Errorf("rejected batch stored %d entries after %d Nutritionix calls", s.Count(), client.calls)
+			}
+		})
+	}
+}
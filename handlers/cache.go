@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Nutritionix cache settings; a TTL of zero disables the cache
+var (
+	cacheTTL        = time.Hour
+	cacheMaxEntries = 1000
+)
+
+// cachedNutrients is one cached Nutritionix response
+type cachedNutrients struct {
+	key       string
+	nutrients NutritionixResponse
+	expires   time.Time
+}
+
+// nutrientCache is an LRU cache of Nutritionix responses keyed by normalized query
+type nutrientCache struct {
+	mu    sync.Mutex
+	order *list.List // most recently used at the front
+	items map[string]*list.Element
+}
+
+// get returns a copy of the cached response for key, dropping it if expired
+func (nc *nutrientCache) get(key string) (NutritionixResponse, bool) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	el, ok := nc.items[key]
+	if !ok {
+		return NutritionixResponse{}, false
+	}
+	item := el.Value.(*cachedNutrients)
+	if time.Now().After(item.expires) {
+		nc.order.Remove(el)
+		delete(nc.items, key)
+		return NutritionixResponse{}, false
+	}
+	nc.order.MoveToFront(el)
+	return cloneNutrients(item.nutrients), true
+}
+
+// put stores a copy of the response, evicting the least recently used entries
+// beyond cacheMaxEntries
+func (nc *nutrientCache) put(key string, nutrients NutritionixResponse) {
+	if cacheTTL <= 0 || cacheMaxEntries <= 0 {
+		return
+	}
+
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	if nc.items == nil {
+		nc.order = list.New()
+		nc.items = make(map[string]*list.Element)
+	}
+
+	item := &cachedNutrients{key: key, nutrients: cloneNutrients(nutrients), expires: time.Now().Add(cacheTTL)}
+	if el, ok := nc.items[key]; ok {
+		el.Value = item
+		nc.order.MoveToFront(el)
+	} else {
+		nc.items[key] = nc.order.PushFront(item)
+	}
+
+	for nc.order.Len() > cacheMaxEntries {
+		oldest := nc.order.Back()
+		nc.order.Remove(oldest)
+		delete(nc.items, oldest.Value.(*cachedNutrients).key)
+	}
+}
+
+// cloneNutrients deep-copies a response so callers can modify their foods
+// without touching the cached copy
+func cloneNutrients(nutrients NutritionixResponse) NutritionixResponse {
+	foods := make([]Food, len(nutrients.Foods))
+	for i, food := range nutrients.Foods {
+		food.AllergenTags = append([]string(nil), food.AllergenTags...)
+		foods[i] = food
+	}
+	nutrients.Foods = foods
+	return nutrients
+}
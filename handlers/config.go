@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// portFlag overrides the PORT env var
+var portFlag = flag.String("port", "", "port to listen on (overrides PORT, default 9000)")
+
+// Config holds the settings main needs to wire up the server; the handler
+// settings are applied to the package by LoadConfig
+type Config struct {
+	Port               string
+	DBPath             string
+	AppID              string
+	AppKey             string
+	NutritionixTimeout time.Duration
+	AutoPurgeEmpty     bool
+}
+
+// Configuration
+var (
+	apiKey string
+
+	maxEntriesPerIPPerDay int
+	rateLimitRPS          float64
+	rateLimitBurst        int
+	foodNameCase          string
+	healthSecret          string
+	maxFoodsPerEntry      = 20
+	maxFoodsMode          = "truncate"
+
+	// allowedCreateFields is nil when every create field is allowed
+	allowedCreateFields map[string]bool
+	createFieldsStrict  bool
+
+	// rejectZeroCalorie makes createEntry refuse queries without calories
+	rejectZeroCalorie bool
+)
+
+// LoadConfig reads the environment (and .env) and the command line flags
+func LoadConfig() (Config, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: No .env file found")
+	}
+	cfg := Config{
+		Port:               "9000",
+		DBPath:             "./nutrition.db",
+		NutritionixTimeout: 30 * time.Second,
+	}
+
+	if v := os.Getenv("PORT"); v != "" {
+		cfg.Port = v
+	}
+	flag.Parse()
+	if *portFlag != "" {
+		cfg.Port = *portFlag
+	}
+	if n, err := strconv.Atoi(cfg.Port); err != nil || n < 1 || n > 65535 {
+		return cfg, fmt.Errorf("invalid port %q, expected a number between 1 and 65535", cfg.Port)
+	}
+
+	cfg.AppID = os.Getenv("APP_ID")
+	cfg.AppKey = os.Getenv("APP_KEY")
+	apiKey = os.Getenv("API_KEY")
+	if apiKey == "" {
+		slog.Warn("API_KEY is not set, write endpoints are open to anyone")
+	}
+
+	foodNameCase = os.Getenv("FOOD_NAME_CASE")
+	if foodNameCase != "" && foodNameCase != "original" && foodNameCase != "title" {
+		return cfg, fmt.Errorf("invalid FOOD_NAME_CASE %q, expected original or title", foodNameCase)
+	}
+
+	if v := os.Getenv("MAX_FOODS_PER_ENTRY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid MAX_FOODS_PER_ENTRY %q", v)
+		}
+		maxFoodsPerEntry = n
+	}
+	if v := os.Getenv("MAX_FOODS_MODE"); v != "" {
+		if v != "truncate" && v != "reject" {
+			return cfg, fmt.Errorf("invalid MAX_FOODS_MODE %q, expected truncate or reject", v)
+		}
+		maxFoodsMode = v
+	}
+
+	if v := os.Getenv("CREATE_ALLOWED_FIELDS"); v != "" {
+		known := make(map[string]bool)
+		for _, name := range createEntryFields {
+			known[name] = true
+		}
+		allowedCreateFields = make(map[string]bool)
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !known[name] {
+				return cfg, fmt.Errorf("invalid CREATE_ALLOWED_FIELDS entry %q, expected any of %s", name, strings.Join(createEntryFields, ", "))
+			}
+			allowedCreateFields[name] = true
+		}
+	}
+	createFieldsStrict = os.Getenv("CREATE_FIELDS_STRICT") == "true"
+	rejectZeroCalorie = os.Getenv("REJECT_ZERO_CALORIE") == "true"
+
+	if v := os.Getenv("GLYCEMIC_INDEX_FILE"); v != "" {
+		if err := loadGlycemicIndex(v); err != nil {
+			return cfg, fmt.Errorf("loading GLYCEMIC_INDEX_FILE: %w", err)
+		}
+	}
+	if v := os.Getenv("CO2_FACTORS_FILE"); v != "" {
+		if err := loadCO2Factors(v); err != nil {
+			return cfg, fmt.Errorf("loading CO2_FACTORS_FILE: %w", err)
+		}
+	}
+	if v := os.Getenv("DEFAULT_CO2_PER_100G"); v != "" {
+		kg, err := strconv.ParseFloat(v, 64)
+		if err != nil || kg < 0 {
+			return cfg, fmt.Errorf("invalid DEFAULT_CO2_PER_100G %q", v)
+		}
+		defaultCO2Per100g = kg
+	}
+	if v := os.Getenv("QUERY_ALIASES_FILE"); v != "" {
+		if err := loadQueryAliases(v); err != nil {
+			return cfg, fmt.Errorf("loading QUERY_ALIASES_FILE: %w", err)
+		}
+	}
+	if v := os.Getenv("DEFAULT_GLYCEMIC_INDEX"); v != "" {
+		gi, err := strconv.ParseFloat(v, 64)
+		if err != nil || gi < 0 || gi > 100 {
+			return cfg, fmt.Errorf("invalid DEFAULT_GLYCEMIC_INDEX %q, expected 0-100", v)
+		}
+		defaultGlycemicIndex = gi
+	}
+
+	healthSecret = os.Getenv("HEALTH_SECRET")
+
+	if v := os.Getenv("ROUNDING_MODE"); v != "" {
+		if !validRoundingMode(v) {
+			return cfg, fmt.Errorf("invalid ROUNDING_MODE %q, expected half_up, half_even or truncate", v)
+		}
+		roundingMode = v
+	}
+
+	if v := os.Getenv("MAX_ENTRIES_PER_IP_PER_DAY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid MAX_ENTRIES_PER_IP_PER_DAY %q", v)
+		}
+		maxEntriesPerIPPerDay = n
+	}
+	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
+		rps, err := strconv.ParseFloat(v, 64)
+		if err != nil || rps < 0 {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_RPS %q", v)
+		}
+		rateLimitRPS = rps
+	}
+	rateLimitBurst = int(rateLimitRPS)
+	if float64(rateLimitBurst) < rateLimitRPS || rateLimitBurst < 1 {
+		rateLimitBurst++
+	}
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid RATE_LIMIT_BURST %q", v)
+		}
+		rateLimitBurst = n
+	}
+
+	if v := os.Getenv("CACHE_TTL_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid CACHE_TTL_SECONDS %q", v)
+		}
+		cacheTTL = time.Duration(n) * time.Second
+	}
+
+	if v := os.Getenv("NUTRITIONIX_TIMEOUT_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid NUTRITIONIX_TIMEOUT_SECONDS %q", v)
+		}
+		cfg.NutritionixTimeout = time.Duration(n) * time.Second
+	}
+
+	if v := os.Getenv("CACHE_MAX_ENTRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid CACHE_MAX_ENTRIES %q", v)
+		}
+		cacheMaxEntries = n
+	}
+
+	cfg.AutoPurgeEmpty = os.Getenv("AUTO_PURGE_EMPTY") == "true"
+	if v := os.Getenv("AUTO_PURGE_INTERVAL_SECONDS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid AUTO_PURGE_INTERVAL_SECONDS %q", v)
+		}
+		autoPurgeInterval = time.Duration(n) * time.Second
+	}
+
+	weatherAPIKey = os.Getenv("WEATHER_API_KEY")
+	weatherLat = os.Getenv("WEATHER_LAT")
+	weatherLon = os.Getenv("WEATHER_LON")
+	if err := validateWeatherLocation(); err != nil {
+		return cfg, err
+	}
+
+	if v := os.Getenv("FIBER_RECOMMENDED_G"); v != "" {
+		g, err := strconv.ParseFloat(v, 64)
+		if err != nil || g <= 0 {
+			return cfg, fmt.Errorf("invalid FIBER_RECOMMENDED_G %q", v)
+		}
+		fiberRecommendedG = g
+	}
+
+	if v := os.Getenv("ALLOWED_ORIGINS"); v != "" {
+		allowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				allowedOrigins = append(allowedOrigins, strings.TrimSuffix(origin, "/"))
+			}
+		}
+	}
+
+	if v := os.Getenv("DB_PATH"); v != "" {
+		cfg.DBPath = v
+	}
+
+	if cfg.AppID == "" || cfg.AppKey == "" {
+		return cfg, fmt.Errorf("missing required environment variables: APP_ID and APP_KEY")
+	}
+
+	return cfg, nil
+}
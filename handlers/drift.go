@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FieldDrift represents the change of a single nutrient field
+type FieldDrift struct {
+	Field   string  `json:"field" example:"nf_calories"`
+	Stored  float64 `json:"stored" example:"205.4"`
+	Current float64 `json:"current" example:"206"`
+	Delta   float64 `json:"delta" example:"0.6"`
+}
+
+// FoodDrift represents how a food differs between stored and current data
+type FoodDrift struct {
+	FoodName string       `json:"food_name" example:"rice"`
+	Status   string       `json:"status" example:"changed" enums:"unchanged,changed,added,removed"`
+	Fields   []FieldDrift `json:"fields,omitempty"`
+}
+
+// DriftResponse represents the comparison of an entry with fresh Nutritionix data
+type DriftResponse struct {
+	EntryID int         `json:"entry_id" example:"1"`
+	Query   string      `json:"query" example:"1 cup rice"`
+	Drifted bool        `json:"drifted" example:"true"`
+	Foods   []FoodDrift `json:"foods"`
+}
+
+// driftFields lists the compared food fields by their JSON names
+var driftFields = []struct {
+	Name  string
+	Value func(Food) float64
+}{
+	{"serving_qty", func(f Food) float64 { return f.ServingQty }},
+	{"serving_weight_grams", func(f Food) float64 { return f.ServingWeight }},
+	{"nf_calories", func(f Food) float64 { return f.NFCalories }},
+	{"nf_protein", func(f Food) float64 { return f.NFProtein }},
+	{"nf_total_fat", func(f Food) float64 { return f.NFTotalFat }},
+	{"nf_total_carbohydrate", func(f Food) float64 { return f.NFTotalCarbs }},
+	{"nf_sodium", func(f Food) float64 { return f.NFSodium }},
+	{"nf_sugars", func(f Food) float64 { return f.NFSugars }},
+	{"nf_dietary_fiber", func(f Food) float64 { return f.NFDietaryFiber }},
+}
+
+// driftEpsilon ignores float noise when comparing nutrient values
+const driftEpsilon = 1e-9
+
+// compareNutrients compares stored and current foods matched by name
+func compareNutrients(stored, current NutritionixResponse) []FoodDrift {
+	remaining := make(map[string][]Food)
+	for _, food := range current.Foods {
+		key := strings.ToLower(food.FoodName)
+		remaining[key] = append(remaining[key], food)
+	}
+
+	var result []FoodDrift
+	for _, old := range stored.Foods {
+		key := strings.ToLower(old.FoodName)
+		if len(remaining[key]) == 0 {
+			result = append(result, FoodDrift{FoodName: old.FoodName, Status: "removed"})
+			continue
+		}
+		fresh := remaining[key][0]
+		remaining[key] = remaining[key][1:]
+
+		drift := FoodDrift{FoodName: old.FoodName, Status: "unchanged"}
+		for _, field := range driftFields {
+			before, after := field.Value(old), field.Value(fresh)
+			if math.Abs(after-before) > driftEpsilon {
+				drift.Fields = append(drift.Fields, FieldDrift{Field: field.Name, Stored: before, Current: after, Delta: after - before})
+			}
+		}
+		if len(drift.Fields) > 0 {
+			drift.Status = "changed"
+		}
+		result = append(result, drift)
+	}
+	for _, food := range current.Foods {
+		if foods := remaining[strings.ToLower(food.FoodName)]; len(foods) > 0 {
+			remaining[strings.ToLower(food.FoodName)] = foods[1:]
+			result = append(result, FoodDrift{FoodName: food.FoodName, Status: "added"})
+		}
+	}
+	return result
+}
+
+// diffNutrients compares stored and current nutrients and reports whether any
+// food changed; drift and refresh both describe changes with it
+func diffNutrients(stored, current NutritionixResponse) (foods []FoodDrift, drifted bool) {
+	foods = compareNutrients(stored, current)
+	for _, food := range foods {
+		if food.Status != "unchanged" {
+			drifted = true
+		}
+	}
+	return foods, drifted
+}
+
+// notFromQueryError explains why an entry built from a recipe, barcode or merge
+// cannot be compared with its re-fetched query
+func notFromQueryError(entry Entry) string {
+	return fmt.Sprintf("Entry source is %s, only entries logged from a query can be re-fetched", entry.Source)
+}
+
+// currentNutrients re-fetches the query of entry, bypassing the cache, and
+// scales the result by the entry's servings
+func (h *Handler) currentNutrients(ctx context.Context, entry Entry) (NutritionixResponse, error) {
+	current, err := h.requestNutrients(ctx, entry.Query)
+	if err != nil {
+		return NutritionixResponse{}, err
+	}
+	if entry.Servings > 0 {
+		current = scaleServings(current, entry.Servings)
+	}
+	return current, nil
+}
+
+// GetEntryDrift godoc
+// @Summary Compare an entry with fresh Nutritionix data
+// @Description Re-fetch the entry's query and report field-by-field differences from the stored nutrients; the entry is not modified. Entries logged from a recipe or barcode, or merged, return 409 since their query does not describe their foods. Use POST /entries/{id}/refresh to save the fresh data
+// @Tags entries
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} DriftResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Entry logged from a recipe, barcode or merge"
+// @Failure 502 {object} ErrorResponse
+// @Router /entries/{id}/drift [get]
+func (h *Handler) getEntryDrift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	entry, exists := h.getUserEntry(id, userIDFrom(c))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if entry.Source != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": notFromQueryError(entry)})
+		return
+	}
+
+	// Bypass the cache, drift is about what Nutritionix returns now
+	current, err := h.currentNutrients(c.Request.Context(), entry)
+	if err != nil {
+		logNutritionixError(c.Request.Context(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch nutrition data"})
+		return
+	}
+
+	resp := DriftResponse{EntryID: entry.ID, Query: entry.Query}
+	resp.Foods, resp.Drifted = diffNutrients(entry.Nutrients, current)
+	respond(c, http.StatusOK, resp)
+}
+
+// RefreshResponse represents an entry saved with fresh Nutritionix data and
+// what changed
+type RefreshResponse struct {
+	Entry   Entry       `json:"entry"`
+	Drifted bool        `json:"drifted" example:"true"`
+	Foods   []FoodDrift `json:"foods"`
+}
+
+// RefreshEntry godoc
+// @Summary Refresh an entry with fresh Nutritionix data
+// @Description Re-fetch the entry's query and save the current nutrients, scaled by its servings, returning the entry and the same field-by-field changes as GET /entries/{id}/drift; the entry is only modified when something drifted, and entries logged from a recipe or barcode, or merged, return 409 like drift. The fresh data goes through the food and calorie limits of POST /entries
+// @Tags entries
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} RefreshResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Entry logged from a recipe, barcode or merge"
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse
+// @Router /entries/{id}/refresh [post]
+func (h *Handler) refreshEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	entry, exists := h.getUserEntry(id, userIDFrom(c))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if entry.Source != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": notFromQueryError(entry)})
+		return
+	}
+
+	current, err := h.currentNutrients(c.Request.Context(), entry)
+	if err != nil {
+		logNutritionixError(c.Request.Context(), err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch nutrition data"})
+		return
+	}
+	truncated, err := checkFoods(entry.Query, &current)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := RefreshResponse{Entry: entry}
+	resp.Foods, resp.Drifted = diffNutrients(entry.Nutrients, current)
+	if resp.Drifted {
+		resp.Entry, err = h.modifyEntry(id, func(entry *Entry) {
+			entry.Nutrients = current
+			entry.Truncated = truncated
+			entry.UpdatedAt = time.Now()
+		})
+		if errors.Is(err, errEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("Storage error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+			return
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,1038 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+)
+
+// Nutritionix types are defined by the client package
+type (
+	NutritionixResponse = nutritionix.Response
+	Food                = nutritionix.Food
+	FoodTags            = nutritionix.FoodTags
+	Photo               = nutritionix.Photo
+)
+
+// SimplifiedEntry represents a simplified nutrition entry response
+type SimplifiedEntry struct {
+	ID           int       `json:"id" example:"1"`
+	Date         string    `json:"date" example:"2025-08-11"`
+	Query        string    `json:"query" example:"1 cup rice"`
+	Meal         string    `json:"meal" example:"lunch" enums:"breakfast,lunch,dinner,snack,uncategorized"`
+	FoodName     string    `json:"food_name" example:"rice"`
+	ServingSize  string    `json:"serving_size" example:"1.0 cup"`
+	Calories     float64   `json:"calories" example:"205.4"`
+	Protein      float64   `json:"protein_g" example:"4.25"`
+	Carbs        float64   `json:"carbs_g" example:"44.51"`
+	Fat          float64   `json:"fat_g" example:"0.44"`
+	GlycemicLoad float64   `json:"glycemic_load" example:"31.9"`   // estimate, see glycemicLoad
+	CO2Estimate  float64   `json:"co2_estimate_kg" example:"0.71"` // estimate, see co2Estimate
+	ImageURL     string    `json:"image_url,omitempty" example:"https://nix-tag-images.s3.amazonaws.com/784_thumb.jpg"`
+	CreatedAt    time.Time `json:"created_at" example:"2025-08-11T10:00:00Z"`
+}
+
+// AllergenFlaggedEntry is an entry annotated with an allergen match
+type AllergenFlaggedEntry struct {
+	Entry
+	ContainsAllergen bool `json:"contains_allergen" example:"false"`
+}
+
+// AllergenFlaggedSimplifiedEntry is a simplified entry annotated with an allergen match
+type AllergenFlaggedSimplifiedEntry struct {
+	SimplifiedEntry
+	ContainsAllergen bool `json:"contains_allergen" example:"false"`
+}
+
+// CreateEntryRequest represents the request body for creating an entry
+type CreateEntryRequest struct {
+	Query  string   `json:"query" binding:"required" example:"1 cup rice" minLength:"1"`
+	Date   string   `json:"date" binding:"required" example:"2025-08-11" format:"date"`
+	Mood   int      `json:"mood" binding:"omitempty,min=1,max=5" example:"4" minimum:"1" maximum:"5"`
+	Energy int      `json:"energy" binding:"omitempty,min=1,max=5" example:"3" minimum:"1" maximum:"5"`
+	Tags   []string `json:"tags" binding:"omitempty,max=10,dive,min=1,max=32" example:"home"`
+	Meal   string   `json:"meal" binding:"omitempty,oneof=breakfast lunch dinner snack" example:"lunch" enums:"breakfast,lunch,dinner,snack"`
+
+	// ClientID is an optional client-generated UUID used to reconcile offline entries
+	ClientID string `json:"client_id" binding:"omitempty,uuid" example:"3f2b8c1e-9a4d-4f6b-8c2e-1d5a7b9c0e12"`
+
+	// Latitude and Longitude optionally record where the food was eaten; set both or neither
+	Latitude  *float64 `json:"latitude" binding:"required_with=Longitude,omitempty,min=-90,max=90" example:"-6.2088"`
+	Longitude *float64 `json:"longitude" binding:"required_with=Latitude,omitempty,min=-180,max=180" example:"106.8456"`
+
+	// Servings multiplies every food Nutritionix returns for the query; defaults to 1
+	Servings *float64 `json:"servings" binding:"omitempty,gt=0" example:"1.5"`
+}
+
+// servings returns the requested multiplier, 1 when omitted
+func (r CreateEntryRequest) servings() float64 {
+	if r.Servings == nil {
+		return 1
+	}
+	return *r.Servings
+}
+
+// PatchEntryRequest represents a partial entry update; omitted fields are left unchanged
+type PatchEntryRequest struct {
+	Date *string `json:"date" example:"2025-08-12" format:"date"`
+}
+
+// PaginatedEntries wraps a page of GET /entries with the filtered total
+type PaginatedEntries struct {
+	Data    interface{} `json:"data"`
+	Total   int         `json:"total" example:"120"`
+	Limit   int         `json:"limit" example:"50"`
+	Offset  int         `json:"offset" example:"0"`
+	HasMore bool        `json:"has_more" example:"true"`
+	// Next and Prev link to the neighbouring pages with the same filters; null at either end
+	Next *string `json:"next" example:"/entries?paginated=true&limit=50&offset=50"`
+	Prev *string `json:"prev" example:"/entries?paginated=true&limit=50&offset=0"`
+}
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Error string `json:"error" example:"Entry not found"`
+}
+
+// CompactResponse represents the result of compacting the ID counter
+type CompactResponse struct {
+	NextID int `json:"next_id" example:"1"`
+}
+
+// HealthResponse represents health check response
+type HealthResponse struct {
+	Status       string            `json:"status" example:"healthy"`
+	Entries      int               `json:"entries" example:"5"`
+	Nutritionix  string            `json:"nutritionix" example:"ok"`
+	Dependencies map[string]string `json:"dependencies"`
+	Timestamp    time.Time         `json:"timestamp" example:"2025-08-11T10:00:00Z"`
+}
+
+// maxTransactionSize bounds the Nutritionix fan-out of a single transaction
+const maxTransactionSize = 25
+
+// Date Helpers
+
+const dateLayout = "2006-01-02"
+
+// dateRange is an inclusive range of entry dates; empty bounds are open
+type dateRange struct {
+	From string
+	To   string
+}
+
+// parseDateRange reads the optional from/to query params
+func parseDateRange(c *gin.Context) (dateRange, error) {
+	r := dateRange{From: c.Query("from"), To: c.Query("to")}
+	for _, d := range []string{r.From, r.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return dateRange{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+	if r.From != "" && r.To != "" && r.From > r.To {
+		return dateRange{}, fmt.Errorf("from must not be after to")
+	}
+	return r, nil
+}
+
+// parseEntryDates reads either an exact date or the from/to range
+func parseEntryDates(c *gin.Context) (dateRange, error) {
+	date := c.Query("date")
+	if date == "" {
+		return parseDateRange(c)
+	}
+	if c.Query("from") != "" || c.Query("to") != "" {
+		return dateRange{}, errors.New("use either date or from/to, not both")
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return dateRange{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	return dateRange{From: date, To: date}, nil
+}
+
+// parseISOWeek returns the Monday of an ISO week written as YYYY-Www
+func parseISOWeek(s string) (time.Time, error) {
+	var year, week int
+	if _, err := fmt.Sscanf(s, "%4d-W%2d", &year, &week); err != nil || len(s) != 8 {
+		return time.Time{}, fmt.Errorf("invalid week %q, expected YYYY-Www", s)
+	}
+
+	// January 4th always falls in ISO week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan4.Weekday()) + 6) % 7
+	monday := jan4.AddDate(0, 0, -offset+(week-1)*7)
+
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid week %q, expected YYYY-Www", s)
+	}
+	return monday, nil
+}
+
+// Contains reports whether an entry date falls inside the range
+func (r dateRange) Contains(date string) bool {
+	if r.From != "" && date < r.From {
+		return false
+	}
+	if r.To != "" && date > r.To {
+		return false
+	}
+	return true
+}
+
+// ===== HANDLERS =====
+
+// GetEntries godoc
+// @Summary Get all nutrition entries
+// @Description Get all nutrition entries with optional simplified format and allergen screening
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param format query string false "Response format (simple)" Enums(simple)
+// @Param avoid query string false "Allergen keyword to screen for (e.g. peanut)"
+// @Param avoid_mode query string false "Drop matching entries (filter, default) or annotate them (flag)" Enums(filter, flag)
+// @Param numbers query string false "Serialize nutrient numbers as fixed-precision strings" Enums(string)
+// @Param photos query string false "Set to false to omit photo and image_url fields" Enums(false)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param date query string false "Only entries of this date" format(date)
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param limit query int false "Maximum number of entries (default 50, max 200)"
+// @Param offset query int false "Number of entries to skip, ordered by ID (default 0)"
+// @Param near query string false "Only entries logged within radius_km of lat,lng; entries without coordinates are excluded" example(-6.2088,106.8456)
+// @Param radius_km query number false "Radius for near in kilometers (default 5, max 20000)"
+// @Param meal query string false "Only entries of this meal; uncategorized selects entries without one" Enums(breakfast, lunch, dinner, snack, uncategorized)
+// @Param paginated query bool false "Wrap the page in an envelope with total, limit, offset and has_more"
+// @Param image query string false "Image resolution of image_url with format=simple (default thumb); highres falls back to thumb when missing" Enums(thumb, highres)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} Entry "Full format entries"
+// @Success 200 {array} SimplifiedEntry "Simplified format entries (when format=simple)"
+// @Success 200 {array} AllergenFlaggedEntry "Flagged entries (when avoid_mode=flag)"
+// @Success 200 {object} PaginatedEntries "Envelope around any of the above (when paginated=true)"
+// @Failure 400 {object} ErrorResponse
+// @Router /entries [get]
+func (h *Handler) getEntries(c *gin.Context) {
+	format := c.Query("format")
+	avoid := c.Query("avoid")
+	avoidMode := c.DefaultQuery("avoid_mode", "filter")
+	if avoidMode != "filter" && avoidMode != "flag" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avoid_mode must be filter or flag"})
+		return
+	}
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dates, err := parseEntryDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	near, err := parseNearFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	highres, err := parseImageSize(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	meal, err := parseMealFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries := []Entry{}
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if dates.Contains(entry.Date) && (near == nil || near.Contains(entry)) && (meal == "" || mealOf(entry) == meal) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if avoid != "" && avoidMode == "filter" {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if !containsAllergen(entry, avoid) {
+				kept = append(kept, entry)
+			}
+		}
+		entries = kept
+	}
+	total := len(entries)
+	entries = paginate(entries, limit, offset)
+
+	var data interface{} = entries
+	switch {
+	case avoid != "" && avoidMode == "flag":
+		data = allergenFlagged(entries, avoid, format == "simple", highres)
+	case format == "simple":
+		simplified := make([]SimplifiedEntry, len(entries))
+		for i, entry := range entries {
+			simplified[i] = toSimplified(entry, highres)
+		}
+		data = simplified
+	}
+
+	if c.Query("paginated") == "true" {
+		page := PaginatedEntries{
+			Data:    data,
+			Total:   total,
+			Limit:   limit,
+			Offset:  offset,
+			HasMore: offset+len(entries) < total,
+		}
+		if page.HasMore {
+			page.Next = pageURL(c, limit, offset+limit)
+		}
+		if offset > 0 {
+			page.Prev = pageURL(c, limit, max(offset-limit, 0))
+		}
+		respond(c, http.StatusOK, page)
+		return
+	}
+	respond(c, http.StatusOK, data)
+}
+
+// Pagination bounds for GET /entries
+const (
+	defaultEntriesLimit = 50
+	maxEntriesLimit     = 200
+)
+
+// parsePagination reads the limit and offset query params
+func parsePagination(c *gin.Context) (limit, offset int, err error) {
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultEntriesLimit)))
+	if err != nil || limit < 0 {
+		return 0, 0, errors.New("limit must be a non-negative integer")
+	}
+	if limit > maxEntriesLimit {
+		return 0, 0, fmt.Errorf("limit must be at most %d", maxEntriesLimit)
+	}
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("offset must be a non-negative integer")
+	}
+	return limit, offset, nil
+}
+
+// parseImageSize reads the image query param, reporting whether highres was requested
+func parseImageSize(c *gin.Context) (highres bool, err error) {
+	switch c.DefaultQuery("image", "thumb") {
+	case "thumb":
+		return false, nil
+	case "highres":
+		return true, nil
+	}
+	return false, errors.New("image must be thumb or highres")
+}
+
+// paginate returns the page of entries, which must already be in a stable order
+func paginate(entries []Entry, limit, offset int) []Entry {
+	if offset >= len(entries) {
+		return []Entry{}
+	}
+	entries = entries[offset:]
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// pageURL returns the request URL with limit and offset replaced, keeping every other filter
+func pageURL(c *gin.Context, limit, offset int) *string {
+	query := c.Request.URL.Query()
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("offset", strconv.Itoa(offset))
+	u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	link := u.String()
+	return &link
+}
+
+// allergenFlagged annotates entries with whether they contain the allergen
+func allergenFlagged(entries []Entry, allergen string, simple, highres bool) interface{} {
+	if simple {
+		flagged := make([]AllergenFlaggedSimplifiedEntry, len(entries))
+		for i, entry := range entries {
+			flagged[i] = AllergenFlaggedSimplifiedEntry{toSimplified(entry, highres), containsAllergen(entry, allergen)}
+		}
+		return flagged
+	}
+
+	flagged := make([]AllergenFlaggedEntry, len(entries))
+	for i, entry := range entries {
+		flagged[i] = AllergenFlaggedEntry{entry, containsAllergen(entry, allergen)}
+	}
+	return flagged
+}
+
+// GetEntryByID godoc
+// @Summary Get nutrition entry by ID
+// @Description Get a specific nutrition entry by its ID with optional simplified format
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param format query string false "Response format (simple)" Enums(simple)
+// @Param numbers query string false "Serialize nutrient numbers as fixed-precision strings" Enums(string)
+// @Param photos query string false "Set to false to omit photo and image_url fields" Enums(false)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param image query string false "Image resolution of image_url with format=simple (default thumb); highres falls back to thumb when missing" Enums(thumb, highres)
+// @Param If-Modified-Since header string false "Return 304 when the entry has not changed since this HTTP date"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} Entry "Full format entry"
+// @Success 200 {object} SimplifiedEntry "Simplified format entry (when format=simple)"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /entries/{id} [get]
+func (h *Handler) getEntryByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	format := c.Query("format")
+	highres, err := parseImageSize(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, exists := h.getUserEntry(id, userIDFrom(c))
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if notModified(c, entry.UpdatedAt) {
+		return
+	}
+
+	if format == "simple" {
+		simplified := toSimplified(entry, highres)
+		respond(c, http.StatusOK, simplified)
+		return
+	}
+
+	respond(c, http.StatusOK, entry)
+}
+
+// UpdateEntry godoc
+// @Summary Update nutrition entry
+// @Description Replace an entry's query, date, servings, meal, tags, mood, energy and location and re-fetch its nutrients from Nutritionix; fields left out of the body are cleared as on create (the meal falls back to uncategorized), while the ID, client_id and created_at are preserved. The body is validated like POST /entries: unknown fields are rejected with 400 and the same food and calorie limits apply. A failed Nutritionix call is reported with the statuses of POST /entries rather than a blanket 500: 422 for an unrecognized food, 502 when Nutritionix is down or errors, and 500 only when it rejects APP_ID/APP_KEY
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param entry body CreateEntryRequest true "Entry data"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse "Body larger than 64 KB"
+// @Failure 422 {object} ErrorResponse "Food not recognized by Nutritionix, too many foods (MAX_FOODS_MODE=reject) or zero calories (REJECT_ZERO_CALORIE=true)"
+// @Failure 500 {object} ErrorResponse "Storage error or Nutritionix rejected APP_ID/APP_KEY"
+// @Failure 502 {object} ErrorResponse "Nutritionix unavailable or returned an error"
+// @Router /entries/{id} [put]
+func (h *Handler) updateEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req CreateEntryRequest
+	if status, err := bindStrictJSON(c, &req); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	req.Query = expandAliases(req.Query)
+
+	if _, exists := h.getUserEntry(id, userIDFrom(c)); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	// Fetch outside the lock so a slow upstream does not block other requests
+	nutrients, truncated, status, err := h.entryNutrients(c.Request.Context(), req.Query)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.modifyEntry(id, func(entry *Entry) {
+		entry.Query = req.Query
+		entry.NormalizedQuery = normalizeQuery(req.Query)
+		entry.Date = req.Date
+		entry.Nutrients = scaleServings(nutrients, req.servings())
+		entry.Servings = storedServings(req.servings())
+		entry.Meal = mealOf(Entry{Meal: req.Meal})
+		entry.Tags = normalizeTags(req.Tags)
+		entry.Mood = req.Mood
+		entry.Energy = req.Energy
+		entry.Latitude = req.Latitude
+		entry.Longitude = req.Longitude
+		entry.Truncated = truncated
+		entry.Source = ""
+		entry.UpdatedAt = time.Now()
+	})
+	if errors.Is(err, errEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	respond(c, http.StatusOK, entry)
+}
+
+// PatchEntry godoc
+// @Summary Partially update nutrition entry
+// @Description Move an entry to another date without re-fetching its nutrients; the query, nutrients, ID and created_at are left untouched, and a body without date returns the entry unchanged
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param id path int true "Entry ID"
+// @Param entry body PatchEntryRequest true "Fields to change"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/{id} [patch]
+func (h *Handler) patchEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req PatchEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	current, exists := h.getUserEntry(id, userIDFrom(c))
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if req.Date == nil {
+		respond(c, http.StatusOK, current)
+		return
+	}
+	if _, err := time.Parse(dateLayout, *req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", *req.Date)})
+		return
+	}
+
+	entry, err := h.modifyEntry(id, func(entry *Entry) {
+		entry.Date = *req.Date
+		entry.UpdatedAt = time.Now()
+	})
+	if errors.Is(err, errEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	respond(c, http.StatusOK, entry)
+}
+
+// notModified sets Last-Modified and answers 304 when If-Modified-Since is not
+// older than it; HTTP dates have second precision, so lastModified is truncated
+func notModified(c *gin.Context, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || lastModified.After(since) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// DeleteEntry godoc
+// @Summary Delete nutrition entry
+// @Description Remove a stored entry by its ID
+// @Tags entries
+// @Param id path int true "Entry ID"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/{id} [delete]
+func (h *Handler) deleteEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if _, exists := h.getUserEntry(id, userIDFrom(c)); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+
+	err = h.removeEntry(id)
+	if errors.Is(err, errEntryNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entry not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entry"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteEntriesResponse represents the result of a bulk delete
+type DeleteEntriesResponse struct {
+	Deleted int `json:"deleted" example:"7"`
+}
+
+// DeleteEntries godoc
+// @Summary Delete entries of a day or all entries
+// @Description Remove every entry of the user logged on date, or all of the user's entries when date is omitted, which requires confirm=true; returns how many were deleted
+// @Tags entries
+// @Produce json
+// @Param date query string false "Only delete entries of this date" format(date)
+// @Param confirm query bool false "Must be true to delete all entries when date is omitted"
+// @Param X-API-Key header string false "API key (required when API_KEY is set)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} DeleteEntriesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries [delete]
+func (h *Handler) deleteEntries(c *gin.Context) {
+	date := c.Query("date")
+	if date != "" {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+			return
+		}
+	} else if c.Query("confirm") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "confirm=true is required to delete all entries"})
+		return
+	}
+
+	var resp DeleteEntriesResponse
+	userID := userIDFrom(c)
+	err := h.updateEntries(func(current map[int]Entry) ([]Entry, []int, error) {
+		var remove []int
+		for id, entry := range current {
+			if entry.UserID == userID && (date == "" || entry.Date == date) {
+				remove = append(remove, id)
+			}
+		}
+		resp.Deleted = len(remove)
+		return nil, remove, nil
+	})
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete entries"})
+		return
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// CreateEntry godoc
+// @Summary Create new nutrition entry
+// @Description Create a new nutrition entry by querying Nutritionix API; unknown fields are rejected with 400, fields outside CREATE_ALLOWED_FIELDS are dropped, or rejected with 400 when CREATE_FIELDS_STRICT=true; servings scales every food before it is stored, so the entry holds the scaled values
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param entry body CreateEntryRequest true "Entry data"
+// @Param split query bool false "Store each returned food as its own entry, linked by group_id"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Param Idempotency-Key header string false "Replays the earlier result of the same key for 24h instead of creating again"
+// @Success 200 {object} Entry "Replay of an earlier create with the same Idempotency-Key"
+// @Success 201 {object} Entry
+// @Success 201 {array} Entry "One entry per food (when split=true)"
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "client_id already exists, or the Idempotency-Key is in use or its entry was deleted"
+// @Failure 413 {object} ErrorResponse "Body larger than 64 KB"
+// @Failure 422 {object} ErrorResponse "Food not recognized by Nutritionix, too many foods (MAX_FOODS_MODE=reject) or zero calories (REJECT_ZERO_CALORIE=true)"
+// @Failure 500 {object} ErrorResponse "Storage error or Nutritionix rejected APP_ID/APP_KEY"
+// @Failure 502 {object} ErrorResponse "Nutritionix unavailable"
+// @Router /entries [post]
+func (h *Handler) createEntry(c *gin.Context) {
+	var req CreateEntryRequest
+	if status, err := bindStrictJSON(c, &req); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	req.Query = expandAliases(req.Query)
+	if req.ClientID != "" && h.clientIDExists(userIDFrom(c), req.ClientID) {
+		c.JSON(http.StatusConflict, gin.H{"error": errDuplicateClientID.Error()})
+		return
+	}
+
+	// Fetch from Nutritionix
+	nutrients, truncated, status, err := h.entryNutrients(c.Request.Context(), req.Query)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Save the entry, or one entry per food when split is set
+	entry := newEntry(req, nutrients)
+	entry.UserID = userIDFrom(c)
+	entry.Truncated = truncated
+	if c.Query("split") == "true" {
+		created, err := h.insertEntries(splitEntry(entry))
+		if errors.Is(err, errDuplicateClientID) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("Storage error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+			return
+		}
+		rememberCreated(c, created, true)
+		respond(c, http.StatusCreated, created)
+		return
+	}
+	created, err := h.insertEntries([]Entry{entry})
+	if errors.Is(err, errDuplicateClientID) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	rememberCreated(c, created, false)
+	respond(c, http.StatusCreated, created[0])
+}
+
+// splitEntry turns an unsaved multi-food entry into one entry per food sharing
+// a group ID; each query describes its food, and only the first keeps the client_id
+func splitEntry(entry Entry) []Entry {
+	group := newGroupID()
+	entries := make([]Entry, len(entry.Nutrients.Foods))
+	for i, food := range entry.Nutrients.Foods {
+		e := entry
+		e.Query = fmt.Sprintf("%s %s %s", strconv.FormatFloat(food.ServingQty, 'f', -1, 64), food.ServingUnit, food.FoodName)
+		e.NormalizedQuery = normalizeQuery(e.Query)
+		e.Nutrients = NutritionixResponse{Foods: []Food{food}}
+		e.GroupID = group
+		if i > 0 {
+			e.ClientID = ""
+		}
+		entries[i] = e
+	}
+	return entries
+}
+
+// newGroupID returns a random identifier linking entries split from one query
+func newGroupID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// errTooManyFoods is returned when a query yields more foods than allowed in reject mode
+var errTooManyFoods = errors.New("query returned too many foods")
+
+// limitFoods enforces MAX_FOODS_PER_ENTRY, truncating or rejecting per MAX_FOODS_MODE
+func limitFoods(query string, nutrients *NutritionixResponse) (truncated bool, err error) {
+	n := len(nutrients.Foods)
+	if n <= maxFoodsPerEntry {
+		return false, nil
+	}
+	if maxFoodsMode == "reject" {
+		return false, fmt.Errorf("%w: %d foods, maximum is %d", errTooManyFoods, n, maxFoodsPerEntry)
+	}
+
+	log.Printf("Truncating %d foods to %d for query %q", n, maxFoodsPerEntry, query)
+	nutrients.Foods = nutrients.Foods[:maxFoodsPerEntry]
+	return true, nil
+}
+
+// errZeroCalories is returned for zero-calorie queries when REJECT_ZERO_CALORIE is on
+var errZeroCalories = errors.New("query has zero calories; water and other zero-calorie items are not logged as food entries, track them with a water tracker instead")
+
+// checkCalories enforces REJECT_ZERO_CALORIE on the fetched nutrients
+func checkCalories(nutrients NutritionixResponse) error {
+	if !rejectZeroCalorie {
+		return nil
+	}
+	var t Totals
+	for _, food := range nutrients.Foods {
+		t.AddFood(food)
+	}
+	if t.Calories <= 0 {
+		return errZeroCalories
+	}
+	return nil
+}
+
+// checkFoods applies MAX_FOODS_PER_ENTRY and REJECT_ZERO_CALORIE to the nutrients
+// of a new or re-fetched entry
+func checkFoods(query string, nutrients *NutritionixResponse) (truncated bool, err error) {
+	if truncated, err = limitFoods(query, nutrients); err != nil {
+		return false, err
+	}
+	return truncated, checkCalories(*nutrients)
+}
+
+// entryNutrients fetches the nutrients of query and checks them with checkFoods;
+// on failure it returns the status and an error whose message is meant for the client
+func (h *Handler) entryNutrients(ctx context.Context, query string) (NutritionixResponse, bool, int, error) {
+	nutrients, err := h.fetchNutrients(ctx, query)
+	if err != nil {
+		if !errors.Is(err, nutritionix.ErrNotFound) {
+			logNutritionixError(ctx, err)
+		}
+		status, msg := nutritionixFailure(err)
+		return NutritionixResponse{}, false, status, errors.New(msg)
+	}
+	truncated, err := checkFoods(query, &nutrients)
+	if err != nil {
+		return NutritionixResponse{}, false, http.StatusUnprocessableEntity, err
+	}
+	return nutrients, truncated, http.StatusOK, nil
+}
+
+// Sources of entries not logged from a natural-language query; re-running
+// their query does not reproduce the stored foods
+const (
+	sourceRecipe  = "recipe"
+	sourceBarcode = "barcode"
+	sourceMerge   = "merge"
+)
+
+// newEntry builds an unsaved entry from a create request and its nutrients
+func newEntry(req CreateEntryRequest, nutrients NutritionixResponse) Entry {
+	now := time.Now()
+	return Entry{
+		Date:            req.Date,
+		Query:           req.Query,
+		NormalizedQuery: normalizeQuery(req.Query),
+		Nutrients:       scaleServings(nutrients, req.servings()),
+		Servings:        storedServings(req.servings()),
+		Mood:            req.Mood,
+		Energy:          req.Energy,
+		Tags:            normalizeTags(req.Tags),
+		Meal:            mealOf(Entry{Meal: req.Meal}),
+		ClientID:        req.ClientID,
+		Latitude:        req.Latitude,
+		Longitude:       req.Longitude,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// scaleServings multiplies every food by servings so the entry stores what was eaten
+func scaleServings(nutrients NutritionixResponse, servings float64) NutritionixResponse {
+	if servings == 1 {
+		return nutrients
+	}
+	foods := make([]Food, len(nutrients.Foods))
+	for i, food := range nutrients.Foods {
+		foods[i] = scaleFood(food, servings)
+	}
+	nutrients.Foods = foods
+	return nutrients
+}
+
+// storedServings is the Entry.Servings value for a multiplier; 1 is left out
+func storedServings(servings float64) float64 {
+	if servings == 1 {
+		return 0
+	}
+	return servings
+}
+
+// normalizeQuery lowercases, trims and collapses whitespace in a food query
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// normalizeTags lowercases, trims and de-duplicates tags
+func normalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+// CreateEntriesTransaction godoc
+// @Summary Create multiple entries atomically
+// @Description Fetch nutrients for every item first and store them all at once; if any lookup fails nothing is stored. Items are validated like POST /entries: unknown fields are rejected with 400 and the same food and calorie limits apply
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param entries body []CreateEntryRequest true "Entries to create"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 201 {array} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "client_id already exists"
+// @Failure 413 {object} ErrorResponse "Body larger than 64 KB"
+// @Failure 422 {object} ErrorResponse "Food not recognized by Nutritionix, too many foods (MAX_FOODS_MODE=reject) or zero calories (REJECT_ZERO_CALORIE=true)"
+// @Failure 500 {object} ErrorResponse "Storage error or Nutritionix rejected APP_ID/APP_KEY"
+// @Failure 502 {object} ErrorResponse "Nutritionix unavailable"
+// @Router /entries/transaction [post]
+func (h *Handler) createEntriesTransaction(c *gin.Context) {
+	var reqs []CreateEntryRequest
+	if status, err := bindStrictJSON(c, &reqs); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxTransactionSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Transaction must contain between 1 and %d entries", maxTransactionSize)})
+		return
+	}
+
+	// Stage every entry before touching the store
+	staged := make([]Entry, len(reqs))
+	for i, req := range reqs {
+		req.Query = expandAliases(req.Query)
+		nutrients, truncated, status, err := h.entryNutrients(c.Request.Context(), req.Query)
+		if err != nil {
+			c.JSON(status, gin.H{"error": fmt.Sprintf("Item %d: %v, transaction rolled back", i, err)})
+			return
+		}
+		staged[i] = newEntry(req, nutrients)
+		staged[i].UserID = userIDFrom(c)
+		staged[i].Truncated = truncated
+	}
+
+	created, err := h.insertEntries(staged)
+	if errors.Is(err, errDuplicateClientID) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error() + ", transaction rolled back"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entries, transaction rolled back"})
+		return
+	}
+
+	respond(c, http.StatusCreated, created)
+}
+
+// CompactEntries godoc
+// @Summary Reset the entry ID counter
+// @Description Reset the next entry ID to 1; only allowed while the store is empty
+// @Tags entries
+// @Produce json
+// @Param X-API-Key header string false "API key (required when API_KEY is set)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} CompactResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/compact [post]
+func (h *Handler) compactEntries(c *gin.Context) {
+	err := h.resetEntryIDs()
+	if errors.Is(err, errStoreNotEmpty) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Store is not empty"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset entry IDs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CompactResponse{NextID: 1})
+}
+
+// Simplification
+
+// toSimplified flattens an entry; image_url is the first food photo, preferring
+// the high resolution one when highres is set
+func toSimplified(entry Entry, highres bool) SimplifiedEntry {
+	simplified := SimplifiedEntry{
+		ID:        entry.ID,
+		Date:      entry.Date,
+		Query:     entry.Query,
+		Meal:      mealOf(entry),
+		CreatedAt: entry.CreatedAt,
+	}
+
+	if len(entry.Nutrients.Foods) > 0 {
+
+		var totalCalories, totalProtein, totalCarbs, totalFat, totalGL, totalCO2 float64
+		var foodNames []string
+		var servingSizes []string
+		var imageURL string
+
+		for _, food := range entry.Nutrients.Foods {
+			totalCalories += food.NFCalories
+			totalProtein += food.NFProtein
+			totalCarbs += food.NFTotalCarbs
+			totalFat += food.NFTotalFat
+			totalGL += glycemicLoad(food)
+			totalCO2 += co2Estimate(food)
+			foodNames = append(foodNames, food.FoodName)
+			servingSizes = append(servingSizes, fmt.Sprintf("%.1f %s", food.ServingQty, food.ServingUnit))
+
+			if imageURL == "" && highres {
+				imageURL = food.Photo.Highres
+			}
+			if imageURL == "" && food.Photo.Thumb != "" {
+				imageURL = food.Photo.Thumb
+			}
+		}
+
+		simplified.FoodName = strings.Join(foodNames, " + ")
+		simplified.ServingSize = strings.Join(servingSizes, " + ")
+		simplified.Calories = roundAmount(totalCalories)
+		simplified.Protein = roundAmount(totalProtein)
+		simplified.Carbs = roundAmount(totalCarbs)
+		simplified.Fat = roundAmount(totalFat)
+		simplified.GlycemicLoad = roundAmount(totalGL)
+		simplified.CO2Estimate = roundAmount(totalCO2)
+		simplified.ImageURL = imageURL
+	}
+
+	return simplified
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersion is the API version recorded in export manifests
+const apiVersion = "1.0"
+
+// entryCSVHeader are the columns written by writeEntriesCSV
+var entryCSVHeader = []string{"id", "date", "query", "food_name", "serving_size", "calories", "protein_g", "carbs_g", "fat_g"}
+
+// entryCSVExtendedHeader are the columns appended when extended is set
+var entryCSVExtendedHeader = []string{"meal", "tags", "created_at"}
+
+// writeEntriesCSV writes one row per entry with its simplified totals; extended
+// appends the entryCSVExtendedHeader columns
+func writeEntriesCSV(w io.Writer, entries []Entry, extended bool) error {
+	cw := csv.NewWriter(w)
+	header := entryCSVHeader
+	if extended {
+		header = append(append([]string(nil), entryCSVHeader...), entryCSVExtendedHeader...)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	formatFloat := func(f float64) string { return strconv.FormatFloat(f, 'f', 2, 64) }
+	for _, entry := range entries {
+		s := toSimplified(entry, false)
+		row := []string{
+			strconv.Itoa(entry.ID),
+			entry.Date,
+			entry.Query,
+			s.FoodName,
+			s.ServingSize,
+			formatFloat(s.Calories),
+			formatFloat(s.Protein),
+			formatFloat(s.Carbs),
+			formatFloat(s.Fat),
+		}
+		if extended {
+			row = append(row, entry.Meal, strings.Join(entry.Tags, ";"), entry.CreatedAt.Format(time.RFC3339))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportManifest describes the contents of a full export archive
+type ExportManifest struct {
+	ExportedAt time.Time `json:"exported_at" example:"2025-08-11T10:00:00Z"`
+	Version    string    `json:"version" example:"1.0"`
+	Entries    int       `json:"entries" example:"42"`
+	Recipes    int       `json:"recipes" example:"3"`
+	Weights    int       `json:"weights" example:"12"`
+	Files      []string  `json:"files" example:"entries.json,entries.csv,goals.json,weights.json,recipes.json"`
+}
+
+// ExportAll godoc
+// @Summary Export the full dataset
+// @Description Download a ZIP archive with the entries (JSON and CSV), goals, weights and recipes of the user and a manifest with the export timestamp and API version
+// @Tags export
+// @Produce application/zip
+// @Param X-API-Key header string false "API key (required when API_KEY is set)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /export/all [get]
+func (h *Handler) exportAll(c *gin.Context) {
+	entries := h.snapshotUserEntries(userIDFrom(c))
+	weights, err := h.store.Weights(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load weights"})
+		return
+	}
+
+	recipeList, err := h.userRecipes(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recipes"})
+		return
+	}
+
+	var goalData interface{}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if ok {
+		goalData = g
+	}
+
+	now := time.Now()
+	manifest := ExportManifest{
+		ExportedAt: now,
+		Version:    apiVersion,
+		Entries:    len(entries),
+		Recipes:    len(recipeList),
+		Weights:    len(weights),
+		Files:      []string{"entries.json", "entries.csv", "goals.json", "weights.json", "recipes.json"},
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="nutrition-export-%s.zip"`, now.Format("20060102")))
+	c.Status(http.StatusOK)
+
+	zw := zip.NewWriter(c.Writer)
+	writeJSON := func(name string, v interface{}) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	// Headers are already sent, so failures can only be logged
+	err = writeJSON("manifest.json", manifest)
+	if err == nil {
+		err = writeJSON("entries.json", entries)
+	}
+	if err == nil {
+		var f io.Writer
+		if f, err = zw.Create("entries.csv"); err == nil {
+			err = writeEntriesCSV(f, entries, true)
+		}
+	}
+	if err == nil {
+		err = writeJSON("goals.json", goalData)
+	}
+	if err == nil {
+		err = writeJSON("weights.json", weights)
+	}
+	if err == nil {
+		err = writeJSON("recipes.json", recipeList)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		log.Printf("Export failed: %v", err)
+	}
+}
+
+// OFFNutriments are Open Food Facts nutriment fields, per serving; OFF uses
+// grams for sodium and salt
+type OFFNutriments struct {
+	EnergyKcal    float64 `json:"energy-kcal_serving" example:"205.4"`
+	Proteins      float64 `json:"proteins_serving" example:"4.25"`
+	Carbohydrates float64 `json:"carbohydrates_serving" example:"44.51"`
+	Fat           float64 `json:"fat_serving" example:"0.44"`
+	Sugars        float64 `json:"sugars_serving" example:"0.08"`
+	Fiber         float64 `json:"fiber_serving" example:"0.63"`
+	Sodium        float64 `json:"sodium_serving" example:"0.00158"`
+	Salt          float64 `json:"salt_serving" example:"0.00395"`
+}
+
+// OFFProduct is the subset of the Open Food Facts product schema filled from a food
+type OFFProduct struct {
+	Code             string        `json:"code" example:"entry-1-0"`
+	ProductName      string        `json:"product_name" example:"rice"`
+	ServingSize      string        `json:"serving_size" example:"1 cup (158 g)"`
+	ServingQuantity  float64       `json:"serving_quantity" example:"158"`
+	NutritionDataPer string        `json:"nutrition_data_per" example:"serving"`
+	Nutriments       OFFNutriments `json:"nutriments"`
+	IngredientsText  string        `json:"ingredients_text,omitempty"`
+	AllergensTags    []string      `json:"allergens_tags,omitempty" example:"en:milk"`
+	ImageURL         string        `json:"image_url,omitempty" example:"https://nix-tag-images.s3.amazonaws.com/784_highres.jpg"`
+	EntryID          int           `json:"entry_id" example:"1"`
+	EntryDate        string        `json:"entry_date" example:"2025-08-11"`
+}
+
+// OFFExport mirrors the shape of an Open Food Facts search response
+type OFFExport struct {
+	Count    int          `json:"count" example:"2"`
+	Products []OFFProduct `json:"products"`
+}
+
+// offSaltPerSodium converts sodium to salt the way Open Food Facts does
+const offSaltPerSodium = 2.5
+
+// toOFFProduct maps the i-th food of an entry to an Open Food Facts product
+func toOFFProduct(entry Entry, i int) OFFProduct {
+	food := entry.Nutrients.Foods[i]
+	serving := fmt.Sprintf("%s %s", strconv.FormatFloat(food.ServingQty, 'f', -1, 64), food.ServingUnit)
+	if food.ServingWeight > 0 {
+		serving += fmt.Sprintf(" (%s g)", strconv.FormatFloat(food.ServingWeight, 'f', -1, 64))
+	}
+
+	product := OFFProduct{
+		Code:             fmt.Sprintf("entry-%d-%d", entry.ID, i),
+		ProductName:      food.FoodName,
+		ServingSize:      serving,
+		ServingQuantity:  food.ServingWeight,
+		NutritionDataPer: "serving",
+		Nutriments: OFFNutriments{
+			EnergyKcal:    food.NFCalories,
+			Proteins:      food.NFProtein,
+			Carbohydrates: food.NFTotalCarbs,
+			Fat:           food.NFTotalFat,
+			Sugars:        food.NFSugars,
+			Fiber:         food.NFDietaryFiber,
+			Sodium:        food.NFSodium / 1000,
+			Salt:          food.NFSodium / 1000 * offSaltPerSodium,
+		},
+		IngredientsText: food.IngredientStatement,
+		ImageURL:        food.Photo.Highres,
+		EntryID:         entry.ID,
+		EntryDate:       entry.Date,
+	}
+	for _, tag := range food.AllergenTags {
+		product.AllergensTags = append(product.AllergensTags, "en:"+strings.ReplaceAll(tag, "_", "-"))
+	}
+	return product
+}
+
+// ExportEntries godoc
+// @Summary Export entries
+// @Description Export stored entries in another format; format=csv streams one row per entry with its simplified totals (extended=true adds the meal, tags and created_at columns), format=off emits one Open Food Facts product per food, so multi-food entries yield several products
+// @Tags export
+// @Produce json
+// @Produce text/csv
+// @Param format query string true "Export format" Enums(csv, off)
+// @Param date query string false "Only entries of this date" format(date)
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param extended query bool false "Add the meal, tags and created_at columns to the CSV"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} OFFExport
+// @Success 200 {file} file "CSV file (when format=csv)"
+// @Failure 400 {object} ErrorResponse
+// @Router /entries/export [get]
+func (h *Handler) exportEntries(c *gin.Context) {
+	dates, err := parseEntryDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.Query("format") {
+	case "csv":
+		entries := []Entry{}
+		for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+			if dates.Contains(entry.Date) {
+				entries = append(entries, entry)
+			}
+		}
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="entries.csv"`)
+		c.Status(http.StatusOK)
+		// Headers are already sent, so failures can only be logged
+		if err := writeEntriesCSV(c.Writer, entries, c.Query("extended") == "true"); err != nil {
+			log.Printf("CSV export failed: %v", err)
+		}
+	case "off":
+		resp := OFFExport{Products: []OFFProduct{}}
+		for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+			if !dates.Contains(entry.Date) {
+				continue
+			}
+			for i := range entry.Nutrients.Foods {
+				resp.Products = append(resp.Products, toOFFProduct(entry, i))
+			}
+		}
+		resp.Count = len(resp.Products)
+		c.JSON(http.StatusOK, resp)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, expected csv or off"})
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportEntriesCSVColumns(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11","meal":"lunch"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	base := []string{"id", "date", "query", "food_name", "serving_size", "calories", "protein_g", "carbs_g", "fat_g"}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"format=csv", base},
+		{"format=csv&extended=true", append(append([]string(nil), base...), "meal", "tags", "created_at")},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, "/entries/export?"+tt.query, "alice", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", tt.query, w.Code, w.Body)
+		}
+		rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if len(rows) != 2 {
+			t.Fatalf("%s: got %d rows, want header and one entry", tt.query, len(rows))
+		}
+		if !reflect.DeepEqual(rows[0], tt.want) {
+			t.Errorf("%s: header = %v, want %v", tt.query, rows[0], tt.want)
+		}
+		if len(rows[1]) != len(tt.want) {
+			t.Errorf("%s: row has %d columns, want %d", tt.query, len(rows[1]), len(tt.want))
+		}
+	}
+}
+
+func TestExportAllIncludesWeights(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/weights", "alice", `{"date":"2025-08-11","weight_kg":72.5}`); w.Code != http.StatusCreated {
+		t.Fatalf("create weight status = %d: %s", w.Code, w.Body)
+	}
+
+	files, decode := exportArchive(t, r, "alice")
+
+	var manifest ExportManifest
+	decode("manifest.json", &manifest)
+	if manifest.Weights != 1 {
+		t.Errorf("manifest weights = %d, want 1", manifest.Weights)
+	}
+	for _, name := range manifest.Files {
+		if _, ok := files[name]; !ok {
+			t.Errorf("manifest lists %s, which is not in the archive", name)
+		}
+	}
+	var weights []WeightEntry
+	decode("weights.json", &weights)
+	if len(weights) != 1 || weights[0].WeightKg != 72.5 {
+		t.Errorf("weights.json = %+v, want the 72.5 kg weight", weights)
+	}
+}
+
+// exportArchive downloads /export/all for user and returns the archive files
+// and a function decoding one of them as JSON
+func exportArchive(t *testing.T, r http.Handler, user string) (map[string]*zip.File, func(name string, v interface{})) {
+	t.Helper()
+	w := serve(r, http.MethodGet, "/export/all", user, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	decode := func(name string, v interface{}) {
+		t.Helper()
+		f, ok := files[name]
+		if !ok {
+			t.Fatalf("archive has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		if err := json.NewDecoder(rc).Decode(v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+	return files, decode
+}
+
+func TestExportAllOnlyIncludesUserData(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	for _, req := range []struct{ method, path, user, body string }{
+		{http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11"}`},
+		{http.MethodPost, "/weights", "alice", `{"date":"2025-08-11","weight_kg":72.5}`},
+		{http.MethodPost, "/recipes", "alice", `{"name":"Oats","ingredients":["1 cup oats"],"servings":1}`},
+		{http.MethodPut, "/goals", "alice", `{"calories":1800}`},
+		{http.MethodPost, "/entries", "bob", `{"query":"1 pear","date":"2025-08-11"}`},
+		{http.MethodPost, "/recipes", "bob", `{"name":"Toast","ingredients":["1 slice bread"],"servings":1}`},
+	} {
+		if w := serve(r, req.method, req.path, req.user, req.body); w.Code != http.StatusCreated && w.Code != http.StatusOK {
+			t.Fatalf("%s %s as %s: status = %d: %s", req.method, req.path, req.user, w.Code, w.Body)
+		}
+	}
+
+	_, decode := exportArchive(t, r, "bob")
+	var manifest ExportManifest
+	decode("manifest.json", &manifest)
+	if manifest.Entries != 1 || manifest.Recipes != 1 || manifest.Weights != 0 {
+		t.Errorf("manifest counts entries=%d recipes=%d weights=%d, want 1, 1 and 0", manifest.Entries, manifest.Recipes, manifest.Weights)
+	}
+	var entries []Entry
+	decode("entries.json", &entries)
+	if len(entries) != 1 || entries[0].Query != "1 pear" {
+		t.Errorf("entries.json = %+v, want only bob's entry", entries)
+	}
+	var recipes []Recipe
+	decode("recipes.json", &recipes)
+	if len(recipes) != 1 || recipes[0].Name != "Toast" {
+		t.Errorf("recipes.json = %+v, want only bob's recipe", recipes)
+	}
+	var weights []WeightEntry
+	decode("weights.json", &weights)
+	if len(weights) != 0 {
+		t.Errorf("weights.json = %+v, want none", weights)
+	}
+	var goal *Goal
+	decode("goals.json", &goal)
+	if goal != nil {
+		t.Errorf("goals.json = %+v, want null", goal)
+	}
+}
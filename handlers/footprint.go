@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// co2Per100g maps a lowercased food name or category word to its carbon
+// footprint in kg CO2e per 100 g; values are rough averages of published life
+// cycle assessments and can be extended via CO2_FACTORS_FILE
+var co2Per100g = map[string]float64{
+	"beef":       9.9,
+	"lamb":       4.0,
+	"mutton":     4.0,
+	"cheese":     2.4,
+	"shrimp":     2.7,
+	"prawn":      2.7,
+	"pork":       1.2,
+	"chicken":    1.0,
+	"turkey":     1.0,
+	"fish":       1.3,
+	"salmon":     1.2,
+	"tuna":       0.6,
+	"eggs":       0.45,
+	"egg":        0.45,
+	"rice":       0.45,
+	"milk":       0.32,
+	"yogurt":     0.25,
+	"tofu":       0.3,
+	"tempeh":     0.3,
+	"coffee":     2.9,
+	"chocolate":  1.9,
+	"pasta":      0.15,
+	"bread":      0.16,
+	"oatmeal":    0.25,
+	"oats":       0.25,
+	"lentils":    0.09,
+	"beans":      0.2,
+	"peanuts":    0.25,
+	"nuts":       0.03,
+	"potato":     0.05,
+	"banana":     0.09,
+	"apple":      0.04,
+	"orange":     0.05,
+	"tomato":     0.21,
+	"vegetables": 0.05,
+	"sugar":      0.32,
+}
+
+// defaultCO2Per100g is used for foods matching no entry of the lookup table
+var defaultCO2Per100g = 0.3
+
+// loadCO2Factors merges a JSON object of food name to kg CO2e per 100 g into the table
+func loadCO2Factors(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var table map[string]float64
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("invalid CO2 factors file: %w", err)
+	}
+	for name, kg := range table {
+		if kg < 0 {
+			return fmt.Errorf("invalid CO2 factor %v for %q, expected 0 or more", kg, name)
+		}
+		co2Per100g[normalizeQuery(name)] = kg
+	}
+	return nil
+}
+
+// co2Factor returns the kg CO2e per 100 g of a food, matching the full name
+// first and then each of its words, so "grilled chicken breast" uses chicken
+func co2Factor(name string) float64 {
+	name = normalizeQuery(name)
+	if kg, ok := co2Per100g[name]; ok {
+		return kg
+	}
+	for _, word := range strings.Fields(name) {
+		if kg, ok := co2Per100g[word]; ok {
+			return kg
+		}
+	}
+	return defaultCO2Per100g
+}
+
+// co2Estimate roughly estimates a food's carbon footprint in kg CO2e from its
+// serving weight; foods without a serving weight count as zero
+func co2Estimate(food Food) float64 {
+	return co2Factor(food.FoodName) * food.ServingWeight / 100
+}
+
+// FoodFootprint represents the estimated footprint of one food over a period
+type FoodFootprint struct {
+	FoodName    string  `json:"food_name" example:"beef"`
+	CO2Estimate float64 `json:"co2_estimate_kg" example:"2.48"`
+}
+
+// FootprintResponse represents the estimated carbon footprint of a period
+type FootprintResponse struct {
+	From         string          `json:"from,omitempty" example:"2025-08-01"`
+	To           string          `json:"to,omitempty" example:"2025-08-31"`
+	Days         int             `json:"days" example:"20"`
+	CO2Estimate  float64         `json:"co2_estimate_kg" example:"31.5"`
+	DailyAverage float64         `json:"daily_average_kg" example:"1.58"`
+	TopFoods     []FoodFootprint `json:"top_foods"`
+	Disclaimer   string          `json:"disclaimer" example:"Rough estimate from average emission factors per 100 g, not a measured value"`
+}
+
+// footprintDisclaimer is returned with every footprint so it is not mistaken for a measurement
+const footprintDisclaimer = "Rough estimate from average emission factors per 100 g, not a measured value"
+
+// maxFootprintFoods caps the foods listed in a footprint response
+const maxFootprintFoods = 10
+
+// GetFootprint godoc
+// @Summary Get carbon footprint estimate
+// @Description Total estimated kg CO2e of the foods logged over a date range, the average per logged day and the foods contributing most; factors are rough per-100 g averages by food name (CO2_FACTORS_FILE) with DEFAULT_CO2_PER_100G for unknown foods
+// @Tags insights
+// @Produce json
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} FootprintResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/footprint [get]
+func (h *Handler) getFootprint(c *gin.Context) {
+	dates, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := FootprintResponse{From: dates.From, To: dates.To, TopFoods: []FoodFootprint{}, Disclaimer: footprintDisclaimer}
+	days := make(map[string]bool)
+	byFood := make(map[string]float64)
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if !dates.Contains(entry.Date) {
+			continue
+		}
+		days[entry.Date] = true
+		for _, food := range entry.Nutrients.Foods {
+			kg := co2Estimate(food)
+			resp.CO2Estimate += kg
+			byFood[normalizeQuery(food.FoodName)] += kg
+		}
+	}
+
+	resp.Days = len(days)
+	if resp.Days > 0 {
+		resp.DailyAverage = roundAmount(resp.CO2Estimate / float64(resp.Days))
+	}
+	resp.CO2Estimate = roundAmount(resp.CO2Estimate)
+	for name, kg := range byFood {
+		resp.TopFoods = append(resp.TopFoods, FoodFootprint{FoodName: name, CO2Estimate: roundAmount(kg)})
+	}
+	sort.Slice(resp.TopFoods, func(i, j int) bool {
+		a, b := resp.TopFoods[i], resp.TopFoods[j]
+		if a.CO2Estimate != b.CO2Estimate {
+			return a.CO2Estimate > b.CO2Estimate
+		}
+		return a.FoodName < b.FoodName
+	})
+	if len(resp.TopFoods) > maxFootprintFoods {
+		resp.TopFoods = resp.TopFoods[:maxFootprintFoods]
+	}
+
+	respond(c, http.StatusOK, resp)
+}
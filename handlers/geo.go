@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// earthRadiusKm is the mean Earth radius used for haversine distances
+const earthRadiusKm = 6371.0
+
+// Radius bounds for the near filter of GET /entries
+const (
+	defaultNearRadiusKm = 5.0
+	maxNearRadiusKm     = 20000.0
+)
+
+// geoFilter matches entries logged within RadiusKm of a point
+type geoFilter struct {
+	Lat, Lng float64
+	RadiusKm float64
+}
+
+// Contains reports whether an entry has coordinates inside the radius;
+// entries without coordinates never match
+func (f *geoFilter) Contains(entry Entry) bool {
+	if entry.Latitude == nil || entry.Longitude == nil {
+		return false
+	}
+	return haversineKm(f.Lat, f.Lng, *entry.Latitude, *entry.Longitude) <= f.RadiusKm
+}
+
+// haversineKm returns the great-circle distance between two points in kilometers
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// validCoordinates reports whether lat and lng are within their ranges
+func validCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// parseNearFilter reads the near=lat,lng and radius_km query params; it
+// returns nil when near is not set
+func parseNearFilter(c *gin.Context) (*geoFilter, error) {
+	near := c.Query("near")
+	if near == "" {
+		if c.Query("radius_km") != "" {
+			return nil, errors.New("radius_km requires near")
+		}
+		return nil, nil
+	}
+
+	parts := strings.Split(near, ",")
+	if len(parts) != 2 {
+		return nil, errors.New("near must be lat,lng")
+	}
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lng, lngErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if latErr != nil || lngErr != nil || !validCoordinates(lat, lng) {
+		return nil, errors.New("near must be lat,lng with latitude between -90 and 90 and longitude between -180 and 180")
+	}
+
+	radius := defaultNearRadiusKm
+	if raw := c.Query("radius_km"); raw != "" {
+		var err error
+		if radius, err = strconv.ParseFloat(raw, 64); err != nil || radius <= 0 || radius > maxNearRadiusKm {
+			return nil, errors.New("radius_km must be greater than 0 and at most 20000")
+		}
+	}
+	return &geoFilter{Lat: lat, Lng: lng, RadiusKm: radius}, nil
+}
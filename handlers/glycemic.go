@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// glycemicIndex maps a lowercased food name to its glycemic index (glucose = 100);
+// values are typical published averages and can be extended via GLYCEMIC_INDEX_FILE
+var glycemicIndex = map[string]float64{
+	"white rice":        73,
+	"rice":              73,
+	"brown rice":        68,
+	"white bread":       75,
+	"bread":             75,
+	"whole wheat bread": 74,
+	"oatmeal":           55,
+	"oats":              55,
+	"cornflakes":        81,
+	"pasta":             49,
+	"spaghetti":         49,
+	"potato":            78,
+	"sweet potato":      63,
+	"banana":            51,
+	"apple":             36,
+	"orange":            43,
+	"watermelon":        76,
+	"milk":              39,
+	"yogurt":            41,
+	"lentils":           32,
+	"chickpeas":         28,
+	"honey":             61,
+	"sugar":             65,
+}
+
+// defaultGlycemicIndex is used for foods missing from the lookup table
+var defaultGlycemicIndex = 55.0
+
+// loadGlycemicIndex merges a JSON object of food name to glycemic index into the table
+func loadGlycemicIndex(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var table map[string]float64
+	if err := json.Unmarshal(data, &table); err != nil {
+		return fmt.Errorf("invalid glycemic index file: %w", err)
+	}
+	for name, gi := range table {
+		if gi < 0 || gi > 100 {
+			return fmt.Errorf("invalid glycemic index %v for %q, expected 0-100", gi, name)
+		}
+		glycemicIndex[normalizeQuery(name)] = gi
+	}
+	return nil
+}
+
+// glycemicLoad estimates a food's glycemic load as GI × available carbs (g) / 100,
+// where available carbs are total carbohydrate minus fiber
+func glycemicLoad(food Food) float64 {
+	gi, ok := glycemicIndex[normalizeQuery(food.FoodName)]
+	if !ok {
+		gi = defaultGlycemicIndex
+	}
+	return gi * math.Max(food.NFTotalCarbs-food.NFDietaryFiber, 0) / 100
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+
+	"fierda/go_nutrition/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Goal represents daily nutrition targets
+type Goal struct {
+	Calories float64 `json:"calories" binding:"gt=0" example:"2000"`
+	Protein  float64 `json:"protein_g" binding:"gte=0" example:"120"`
+	Carbs    float64 `json:"carbs_g" binding:"gte=0" example:"250"`
+	Fat      float64 `json:"fat_g" binding:"gte=0" example:"65"`
+
+	// Maintenance is the estimated daily energy expenditure (TDEE), if known
+	Maintenance float64 `json:"maintenance_calories,omitempty" binding:"gte=0" example:"2300"`
+}
+
+// currentGoal returns the goal userID configured, if any
+func (h *Handler) currentGoal(userID string) (Goal, bool, error) {
+	g, ok, err := h.store.GetGoal(userID)
+	return Goal(g), ok, err
+}
+
+// setGoal replaces the goal of userID and returns the stored goal;
+// keepMaintenance carries over the maintenance calories of the goal it replaces
+func (h *Handler) setGoal(userID string, g Goal, keepMaintenance bool) (Goal, error) {
+	h.goalMu.Lock()
+	defer h.goalMu.Unlock()
+
+	if keepMaintenance {
+		old, ok, err := h.currentGoal(userID)
+		if err != nil {
+			return Goal{}, err
+		}
+		if ok {
+			g.Maintenance = old.Maintenance
+		}
+	}
+	if err := h.store.SaveGoal(userID, store.Goal(g)); err != nil {
+		return Goal{}, err
+	}
+	return g, nil
+}
+
+// GetGoals godoc
+// @Summary Get daily goals
+// @Description Get the daily nutrition goals of the user
+// @Tags goals
+// @Produce json
+// @Param X-User-ID header string true "User whose goals are read or written"
+// @Success 200 {object} Goal
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /goals [get]
+func (h *Handler) getGoals(c *gin.Context) {
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No goals set"})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// PutGoals godoc
+// @Summary Set daily goals
+// @Description Replace the daily nutrition goals of the user
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param goals body Goal true "Daily goals"
+// @Param X-User-ID header string true "User whose goals are read or written"
+// @Success 200 {object} Goal
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /goals [put]
+func (h *Handler) putGoals(c *gin.Context) {
+	var req Goal
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	g, err := h.setGoal(userIDFrom(c), req, false)
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save goals"})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
+
+// Energy per gram of each macronutrient
+const (
+	kcalPerGramProtein = 4
+	kcalPerGramCarbs   = 4
+	kcalPerGramFat     = 9
+)
+
+// MacroSplitRequest represents daily calories split by macro percentages
+type MacroSplitRequest struct {
+	Calories   float64 `json:"calories" binding:"required,gt=0" example:"2000"`
+	ProteinPct float64 `json:"protein_pct" binding:"gte=0,lte=100" example:"30"`
+	CarbsPct   float64 `json:"carbs_pct" binding:"gte=0,lte=100" example:"40"`
+	FatPct     float64 `json:"fat_pct" binding:"gte=0,lte=100" example:"30"`
+}
+
+// PutGoalsFromSplit godoc
+// @Summary Set daily goals from a macro split
+// @Description Compute gram targets from total calories and protein/carb/fat percentages (4/4/9 kcal per gram) and store them as the daily goals; any maintenance calories already set are kept
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param split body MacroSplitRequest true "Calories and macro percentages"
+// @Param X-User-ID header string true "User whose goals are read or written"
+// @Success 200 {object} Goal
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /goals/from-split [post]
+func (h *Handler) putGoalsFromSplit(c *gin.Context) {
+	var req MacroSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if sum := req.ProteinPct + req.CarbsPct + req.FatPct; math.Abs(sum-100) > 0.01 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Percentages must sum to 100, got %g", sum)})
+		return
+	}
+
+	g := Goal{
+		Calories: req.Calories,
+		Protein:  roundAmount(req.Calories * req.ProteinPct / 100 / kcalPerGramProtein),
+		Carbs:    roundAmount(req.Calories * req.CarbsPct / 100 / kcalPerGramCarbs),
+		Fat:      roundAmount(req.Calories * req.FatPct / 100 / kcalPerGramFat),
+	}
+
+	g, err := h.setGoal(userIDFrom(c), g, true)
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save goals"})
+		return
+	}
+	c.JSON(http.StatusOK, g)
+}
@@ -0,0 +1,37 @@
+// Package handlers implements the HTTP API of the nutrition tracker
+package handlers
+
+import (
+	"sync"
+
+	"fierda/go_nutrition/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+// Handler serves the API from an entry store and a Nutritionix client; the
+// caches and in-flight state that belong to them live here as well
+type Handler struct {
+	store       store.Store
+	nutritionix Nutritionix
+
+	cache       nutrientCache
+	flight      singleflight.Group // collapses concurrent misses for the same normalized query
+	idempotency idempotencyKeys
+	health      nutritionixCheck
+
+	goalMu sync.Mutex // serializes goal updates that keep the previous maintenance calories
+}
+
+// New returns a Handler backed by s and client
+func New(s store.Store, client Nutritionix) *Handler {
+	return &Handler{store: s, nutritionix: client}
+}
+
+// EntriesCollector reports the number of stored entries across all users
+func (h *Handler) EntriesCollector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "nutrition_entries",
+		Help: "Number of stored entries across all users.",
+	}, func() float64 { return float64(h.countEntries()) })
+}
@@ -0,0 +1,730 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+	"fierda/go_nutrition/store"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeStore is an in-memory store.Store
+type fakeStore struct {
+	mu      sync.Mutex
+	entries map[int]store.Entry
+	nextID  int
+	goals   map[string]store.Goal              // user -> goal
+	weights map[string]map[string]store.Weight // user -> date -> weight
+	recipes map[string]map[string]store.Recipe // user -> key -> recipe
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{entries: make(map[int]store.Entry), nextID: 1, goals: make(map[string]store.Goal), weights: make(map[string]map[string]store.Weight), recipes: make(map[string]map[string]store.Recipe)}
+}
+
+func (s *fakeStore) Get(id int) (store.Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	return entry, ok
+}
+
+func (s *fakeStore) List() []store.Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]store.Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+func (s *fakeStore) Create(entries []store.Entry) ([]store.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		if entry.ClientID != "" && s.clientIDExists(entry.UserID, entry.ClientID) {
+			return nil, store.ErrDuplicateClientID
+		}
+	}
+	for i := range entries {
+		entries[i].ID = s.nextID
+		s.nextID++
+		s.entries[entries[i].ID] = entries[i]
+	}
+	return entries, nil
+}
+
+func (s *fakeStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return store.ErrEntryNotFound
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *fakeStore) Update(fn store.UpdateFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	save, remove, err := fn(s.entries)
+	if err != nil {
+		return err
+	}
+	for _, entry := range save {
+		s.entries[entry.ID] = entry
+	}
+	for _, id := range remove {
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+func (s *fakeStore) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *fakeStore) ClientIDExists(userID, clientID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientIDExists(userID, clientID)
+}
+
+func (s *fakeStore) clientIDExists(userID, clientID string) bool {
+	for _, entry := range s.entries {
+		if entry.UserID == userID && entry.ClientID == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeStore) ResetIDs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) > 0 {
+		return store.ErrStoreNotEmpty
+	}
+	s.nextID = 1
+	return nil
+}
+
+func (s *fakeStore) SaveGoal(userID string, g store.Goal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.goals[userID] = g
+	return nil
+}
+
+func (s *fakeStore) GetGoal(userID string) (store.Goal, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.goals[userID]
+	return g, ok, nil
+}
+
+func (s *fakeStore) SaveWeight(userID string, w store.Weight) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.weights[userID] == nil {
+		s.weights[userID] = make(map[string]store.Weight)
+	}
+	s.weights[userID][w.Date] = w
+	return nil
+}
+
+func (s *fakeStore) Weights(userID string) ([]store.Weight, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	weights := []store.Weight{}
+	for _, w := range s.weights[userID] {
+		weights = append(weights, w)
+	}
+	sort.Slice(weights, func(i, j int) bool { return weights[i].Date < weights[j].Date })
+	return weights, nil
+}
+
+func (s *fakeStore) CreateRecipe(userID, key string, r store.Recipe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.recipes[userID][key]; exists {
+		return store.ErrRecipeExists
+	}
+	if s.recipes[userID] == nil {
+		s.recipes[userID] = make(map[string]store.Recipe)
+	}
+	s.recipes[userID][key] = r
+	return nil
+}
+
+func (s *fakeStore) GetRecipe(userID, key string) (store.Recipe, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.recipes[userID][key]
+	return r, ok, nil
+}
+
+func (s *fakeStore) Recipes(userID string) ([]store.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	recipes := []store.Recipe{}
+	for _, r := range s.recipes[userID] {
+		recipes = append(recipes, r)
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].Name < recipes[j].Name })
+	return recipes, nil
+}
+
+func (s *fakeStore) Ping(ctx context.Context) error { return nil }
+
+func (s *fakeStore) Close() error { return nil }
+
+// stubNutritionix answers a query with one food per part joined by " and ",
+// each of 100 kcal per word, except queries containing "unknown", which it
+// does not recognize
+type stubNutritionix struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (n *stubNutritionix) Nutrients(ctx context.Context, query string) (nutritionix.Response, error) {
+	n.mu.Lock()
+	n.calls++
+	n.mu.Unlock()
+	if strings.Contains(query, "unknown") {
+		return nutritionix.Response{}, &nutritionix.StatusError{StatusCode: http.StatusNotFound}
+	}
+	var resp nutritionix.Response
+	for _, part := range strings.Split(query, " and ") {
+		resp.Foods = append(resp.Foods, nutritionix.Food{
+			FoodName:     part,
+			ServingQty:   1,
+			ServingUnit:  "serving",
+			NFCalories:   100 * float64(len(strings.Fields(part))),
+			NFProtein:    10,
+			NFTotalCarbs: 20,
+			NFTotalFat:   5,
+		})
+	}
+	return resp, nil
+}
+
+func (n *stubNutritionix) Search(ctx context.Context, query string) (nutritionix.SearchResponse, error) {
+	return nutritionix.SearchResponse{}, nil
+}
+
+// Item knows a single 250 kcal granola bar per barcode, or none for all zeros
+func (n *stubNutritionix) Item(ctx context.Context, upc string) ([]nutritionix.ItemFood, error) {
+	if strings.Trim(upc, "0") == "" {
+		return nil, &nutritionix.StatusError{StatusCode: http.StatusNotFound}
+	}
+	return []nutritionix.ItemFood{{
+		Food:      nutritionix.Food{FoodName: "granola bar", ServingQty: 1, ServingUnit: "bar", NFCalories: 250},
+		BrandName: "Acme",
+	}}, nil
+}
+
+// newTestRouter returns the API routes backed by a fake store and a stub client
+func newTestRouter(t *testing.T) (*gin.Engine, *fakeStore, *stubNutritionix) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s, client := newFakeStore(), &stubNutritionix{}
+	r := gin.New()
+	New(s, client).Register(r)
+	return r, s, client
+}
+
+// serve sends a JSON request as user and returns the recorded response
+func serve(r http.Handler, method, path, user, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set("X-User-ID", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateEntry(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+
+	w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var created Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	stored, ok := s.Get(created.ID)
+	if !ok {
+		t.Fatalf("entry %d was not stored", created.ID)
+	}
+	if stored.UserID != "alice" || stored.Date != "2025-08-11" {
+		t.Errorf("stored entry = %+v, want alice on 2025-08-11", stored)
+	}
+	if got := entryTotals(stored).Calories; got != 200 {
+		t.Errorf("calories = %v, want 200", got)
+	}
+
+	if w := serve(r, http.MethodGet, "/entries/1", "alice", ""); w.Code != http.StatusOK {
+		t.Errorf("owner GET status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if w := serve(r, http.MethodGet, "/entries/1", "bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("other user GET status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestCreateEntryUnrecognizedFood(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+
+	w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"unknown thing","date":"2025-08-11"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body)
+	}
+	if n := s.Count(); n != 0 {
+		t.Errorf("stored %d entries, want 0", n)
+	}
+}
+
+func TestAggregatesAreScopedToUser(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+
+	for _, req := range []struct{ user, body string }{
+		{"alice", `{"query":"1 apple","date":"2025-08-11","client_id":"3f2b8c1e-9a4d-4f6b-8c2e-1d5a7b9c0e12"}`},
+		{"bob", `{"query":"rice","date":"2025-08-11","client_id":"3f2b8c1e-9a4d-4f6b-8c2e-1d5a7b9c0e12"}`},
+	} {
+		if w := serve(r, http.MethodPost, "/entries", req.user, req.body); w.Code != http.StatusCreated {
+			t.Fatalf("%s create status = %d, want %d: %s", req.user, w.Code, http.StatusCreated, w.Body)
+		}
+	}
+	if w := serve(r, http.MethodPut, "/goals", "alice", `{"calories":1800}`); w.Code != http.StatusOK {
+		t.Fatalf("put goals status = %d: %s", w.Code, w.Body)
+	}
+
+	w := serve(r, http.MethodGet, "/summary", "bob", "")
+	var summary []DailySummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary) != 1 || summary[0].Entries != 1 || summary[0].Calories != 100 {
+		t.Errorf("bob's summary = %+v, want one entry of 100 kcal", summary)
+	}
+	if w := serve(r, http.MethodGet, "/goals", "bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bob's goals status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(r, http.MethodGet, "/summary", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("summary without X-User-ID status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestGoalsAreSavedInStore(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPut, "/goals", "alice", `{"calories":2000,"maintenance_calories":2300}`); w.Code != http.StatusOK {
+		t.Fatalf("put goals status = %d: %s", w.Code, w.Body)
+	}
+	w := serve(r, http.MethodPost, "/goals/from-split", "alice", `{"calories":1800,"protein_pct":30,"carbs_pct":40,"fat_pct":30}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("from-split status = %d: %s", w.Code, w.Body)
+	}
+
+	want := store.Goal{Calories: 1800, Protein: 135, Carbs: 180, Fat: 60, Maintenance: 2300}
+	got, ok, err := s.GetGoal("alice")
+	if err != nil || !ok {
+		t.Fatalf("stored goal: ok = %v, err = %v", ok, err)
+	}
+	if got != want {
+		t.Errorf("stored goal = %+v, want %+v", got, want)
+	}
+}
+
+func TestGetEntryConditional(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"apple","date":"2025-08-11"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	get := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/entries/1", nil)
+		req.Header.Set("X-User-ID", "alice")
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("")
+	validator := w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || validator == "" {
+		t.Fatalf("status = %d, Last-Modified = %q, want 200 with a validator", w.Code, validator)
+	}
+
+	w = get(validator)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("revalidation status = %d, want %d", w.Code, http.StatusNotModified)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("304 body = %q, want empty", w.Body)
+	}
+
+	// An update bumps the timestamp, so the old validator no longer matches
+	s.Update(func(current map[int]Entry) ([]Entry, []int, error) {
+		entry := current[1]
+		entry.UpdatedAt = entry.UpdatedAt.Add(time.Minute)
+		return []Entry{entry}, nil, nil
+	})
+	if w := get(validator); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("status after update = %d with %d bytes, want 200 with the entry", w.Code, w.Body.Len())
+	}
+}
+
+func TestCreateEntryLimitsFoods(t *testing.T) {
+	parts := make([]string, maxFoodsPerEntry+5)
+	for i := range parts {
+		parts[i] = fmt.Sprintf("food%d", i)
+	}
+	body := fmt.Sprintf(`{"query":%q,"date":"2025-08-11"}`, strings.Join(parts, " and "))
+
+	t.Run("truncate", func(t *testing.T) {
+		r, _, _ := newTestRouter(t)
+		w := serve(r, http.MethodPost, "/entries", "alice", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+		}
+		var created Entry
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(created.Nutrients.Foods); n != maxFoodsPerEntry {
+			t.Errorf("stored %d foods, want %d", n, maxFoodsPerEntry)
+		}
+		if last := created.Nutrients.Foods[len(created.Nutrients.Foods)-1].FoodName; last != parts[maxFoodsPerEntry-1] {
+			t.Errorf("last food = %q, want %q", last, parts[maxFoodsPerEntry-1])
+		}
+		if !created.Truncated {
+			t.Error("foods_truncated = false, want true")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		defer func(mode string) { maxFoodsMode = mode }(maxFoodsMode)
+		maxFoodsMode = "reject"
+
+		r, s, _ := newTestRouter(t)
+		w := serve(r, http.MethodPost, "/entries", "alice", body)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body)
+		}
+		if n := s.Count(); n != 0 {
+			t.Errorf("stored %d entries, want 0", n)
+		}
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		r, _, _ := newTestRouter(t)
+		w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"rice and beans","date":"2025-08-11"}`)
+		var created Entry
+		if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+			t.Fatal(err)
+		}
+		if len(created.Nutrients.Foods) != 2 || created.Truncated {
+			t.Errorf("got %d foods, truncated %v, want 2 foods untruncated", len(created.Nutrients.Foods), created.Truncated)
+		}
+	})
+}
+
+func TestUpdateEntryValidatesLikeCreate(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"apple","date":"2025-08-11"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"unknown field", `{"query":"rice","date":"2025-08-11","calories":5}`, http.StatusBadRequest},
+		{"unrecognized food", `{"query":"unknown thing","date":"2025-08-11"}`, http.StatusUnprocessableEntity},
+		{"valid", `{"query":"2 cups rice","date":"2025-08-12"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodPut, "/entries/1", "alice", tt.body)
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.wantCode, w.Body)
+		}
+	}
+
+	entry, _ := s.Get(1)
+	if entry.Query != "2 cups rice" || entry.Date != "2025-08-12" || entryTotals(entry).Calories != 300 {
+		t.Errorf("updated entry = %q on %s with %v kcal, want 2 cups rice on 2025-08-12 with 300 kcal",
+			entry.Query, entry.Date, entryTotals(entry).Calories)
+	}
+}
+
+func TestUpdateEntryReplacesMetadata(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	created := `{"query":"apple","date":"2025-08-11","meal":"breakfast","tags":["home"],"mood":4,"latitude":-6.2,"longitude":106.8}`
+	if w := serve(r, http.MethodPost, "/entries", "alice", created); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	if w := serve(r, http.MethodPut, "/entries/1", "alice", `{"query":"apple","date":"2025-08-11","meal":"dinner","tags":["Work"],"energy":2}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", w.Code, w.Body)
+	}
+	entry, _ := s.Get(1)
+	if entry.Meal != "dinner" || !reflect.DeepEqual(entry.Tags, []string{"work"}) || entry.Mood != 0 || entry.Energy != 2 {
+		t.Errorf("updated entry meal = %q, tags = %v, mood = %d, energy = %d, want dinner, [work], 0 and 2",
+			entry.Meal, entry.Tags, entry.Mood, entry.Energy)
+	}
+	if entry.Latitude != nil || entry.Longitude != nil {
+		t.Errorf("updated entry kept its location, want none")
+	}
+
+	if w := serve(r, http.MethodPut, "/entries/1", "alice", `{"query":"apple","date":"2025-08-11"}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT without meal status = %d: %s", w.Code, w.Body)
+	}
+	if entry, _ := s.Get(1); entry.Meal != mealUncategorized {
+		t.Errorf("meal = %q after PUT without meal, want %q", entry.Meal, mealUncategorized)
+	}
+}
+
+func TestCreateEntriesTransactionRollsBack(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"unknown field", `[{"query":"apple","date":"2025-08-11","calories":5}]`, http.StatusBadRequest},
+		{"unrecognized food", `[{"query":"apple","date":"2025-08-11"},{"query":"unknown thing","date":"2025-08-11"}]`, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, s, _ := newTestRouter(t)
+			w := serve(r, http.MethodPost, "/entries/transaction", "alice", tt.body)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if n := s.Count(); n != 0 {
+				t.Errorf("stored %d entries, want 0", n)
+			}
+		})
+	}
+}
+
+func TestListEntriesPageLinks(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	for i := 0; i < 5; i++ {
+		if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11","meal":"lunch"}`); w.Code != http.StatusCreated {
+			t.Fatalf("create status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	link := func(s string) *string { return &s }
+	tests := []struct {
+		offset     string
+		next, prev *string
+	}{
+		{"0", link("/entries?limit=2&meal=lunch&offset=2&paginated=true"), nil},
+		{"2", link("/entries?limit=2&meal=lunch&offset=4&paginated=true"), link("/entries?limit=2&meal=lunch&offset=0&paginated=true")},
+		{"3", nil, link("/entries?limit=2&meal=lunch&offset=1&paginated=true")},
+	}
+	str := func(s *string) string {
+		if s == nil {
+			return "null"
+		}
+		return *s
+	}
+	for _, tt := range tests {
+		w := serve(r, http.MethodGet, "/entries?paginated=true&meal=lunch&limit=2&offset="+tt.offset, "alice", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("offset %s: status = %d: %s", tt.offset, w.Code, w.Body)
+		}
+		var page PaginatedEntries
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		if str(page.Next) != str(tt.next) || str(page.Prev) != str(tt.prev) {
+			t.Errorf("offset %s: next, prev = %s, %s, want %s, %s", tt.offset, str(page.Next), str(page.Prev), str(tt.next), str(tt.prev))
+		}
+	}
+}
+
+func TestCreateEntryFromBarcodeSharesCreateChecks(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	w := serve(r, http.MethodPost, "/entries/barcode/012345678905", "alice", `{"date":"2025-08-11","servings":2}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var created Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Query != "Acme granola bar" || created.Servings != 2 {
+		t.Errorf("entry query, servings = %q, %v, want Acme granola bar, 2", created.Query, created.Servings)
+	}
+	if got := entryTotals(created).Calories; got != 500 {
+		t.Errorf("calories = %v, want 500", got)
+	}
+
+	if w := serve(r, http.MethodPost, "/entries/barcode/00000000", "alice", `{"date":"2025-08-11"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown barcode status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(r, http.MethodPost, "/entries/barcode/012345678905", "alice", `{"date":"2025-08-11","servings":0}`); w.Code != http.StatusBadRequest {
+		t.Errorf("zero servings status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	defer func(limit int, mode string) { maxFoodsPerEntry, maxFoodsMode = limit, mode }(maxFoodsPerEntry, maxFoodsMode)
+	maxFoodsPerEntry, maxFoodsMode = 0, "reject"
+	if w := serve(r, http.MethodPost, "/entries/barcode/012345678905", "alice", `{"date":"2025-08-11"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("too many foods status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if n := s.Count(); n != 1 {
+		t.Errorf("stored %d entries, want 1", n)
+	}
+}
+
+func TestMergeEntriesJoinsQueries(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	for _, body := range []string{
+		`{"query":"1 Apple","date":"2025-08-11"}`,
+		`{"query":"1 banana","date":"2025-08-11","servings":2}`,
+		`{"query":"2 eggs","date":"2025-08-11"}`,
+	} {
+		if w := serve(r, http.MethodPost, "/entries", "alice", body); w.Code != http.StatusCreated {
+			t.Fatalf("create status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	w := serve(r, http.MethodPost, "/entries/merge", "alice", `{"ids":[3,2,1],"keep":2}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("merge status = %d: %s", w.Code, w.Body)
+	}
+	var kept Entry
+	if err := json.Unmarshal(w.Body.Bytes(), &kept); err != nil {
+		t.Fatal(err)
+	}
+	if kept.ID != 2 || len(kept.Nutrients.Foods) != 3 {
+		t.Errorf("kept entry %d has %d foods, want entry 2 with 3", kept.ID, len(kept.Nutrients.Foods))
+	}
+	if want := "1 banana and 2 eggs and 1 Apple"; kept.Query != want || kept.NormalizedQuery != normalizeQuery(want) {
+		t.Errorf("query = %q (%q), want %q", kept.Query, kept.NormalizedQuery, want)
+	}
+	// 400 (banana, 2 servings) + 200 (eggs) + 200 (apple), with no servings left to apply
+	if got := entryTotals(kept).Calories; kept.Servings != 0 || got != 800 {
+		t.Errorf("servings, calories = %v, %v, want 0, 800", kept.Servings, got)
+	}
+
+	if w := serve(r, http.MethodPost, "/entries/merge", "alice", `{"ids":[2,3],"keep":2,"force":true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown field status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	w = serve(r, http.MethodPost, "/entries/merge", "bob", `{"ids":[2,4],"keep":2}`)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "entry 2 not found") {
+		t.Errorf("other user merge = %d %s, want 404 entry 2 not found", w.Code, w.Body)
+	}
+}
+
+func TestRefreshEntrySavesDrift(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11","servings":2}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+	// Make the stored nutrients stale
+	s.Update(func(current map[int]Entry) ([]Entry, []int, error) {
+		entry := current[1]
+		entry.Nutrients = NutritionixResponse{Foods: []Food{{FoodName: "1 apple", NFCalories: 150}}}
+		return []Entry{entry}, nil, nil
+	})
+
+	refresh := func() RefreshResponse {
+		w := serve(r, http.MethodPost, "/entries/1/refresh", "alice", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("refresh status = %d: %s", w.Code, w.Body)
+		}
+		var resp RefreshResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := refresh()
+	if !resp.Drifted || len(resp.Foods) != 1 || resp.Foods[0].Status != "changed" {
+		t.Errorf("refresh = %+v, want one changed food", resp)
+	}
+	stored, _ := s.Get(1)
+	if got := entryTotals(stored).Calories; got != 400 {
+		t.Errorf("stored calories = %v, want 400 for 2 servings", got)
+	}
+	if got := entryTotals(resp.Entry).Calories; got != 400 {
+		t.Errorf("returned calories = %v, want 400", got)
+	}
+
+	if resp := refresh(); resp.Drifted {
+		t.Errorf("second refresh drifted: %+v", resp.Foods)
+	}
+	if w := serve(r, http.MethodPost, "/entries/1/refresh", "bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("other user refresh status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestDriftRejectsEntriesNotFromQuery(t *testing.T) {
+	r, s, client := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/recipes", "alice", `{"name":"oats","ingredients":["1 cup oats","1 cup milk"],"servings":2}`); w.Code != http.StatusCreated {
+		t.Fatalf("create recipe status = %d: %s", w.Code, w.Body)
+	}
+	for _, path := range []string{"/entries/from-recipe/oats", "/entries/barcode/012345678905"} {
+		if w := serve(r, http.MethodPost, path, "alice", `{"date":"2025-08-11"}`); w.Code != http.StatusCreated {
+			t.Fatalf("POST %s status = %d: %s", path, w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		id     int
+		source string
+	}{
+		{"recipe", 1, sourceRecipe},
+		{"barcode", 2, sourceBarcode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := s.Get(tt.id)
+			if before.Source != tt.source {
+				t.Fatalf("source = %q, want %q", before.Source, tt.source)
+			}
+			calls := client.calls
+			for _, req := range []struct{ method, path string }{
+				{http.MethodGet, fmt.Sprintf("/entries/%d/drift", tt.id)},
+				{http.MethodPost, fmt.Sprintf("/entries/%d/refresh", tt.id)},
+			} {
+				if w := serve(r, req.method, req.path, "alice", ""); w.Code != http.StatusConflict {
+					t.Errorf("%s %s status = %d, want %d: %s", req.method, req.path, w.Code, http.StatusConflict, w.Body)
+				}
+			}
+			if client.calls != calls {
+				t.Errorf("Nutritionix was queried with the %s name", tt.name)
+			}
+			if after, _ := s.Get(tt.id); !reflect.DeepEqual(after, before) {
+				t.Errorf("entry changed to %+v", after)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+)
+
+// LivenessResponse is the minimal health status that exposes no internal metrics
+type LivenessResponse struct {
+	Status    string    `json:"status" example:"healthy"`
+	Timestamp time.Time `json:"timestamp" example:"2025-08-11T10:00:00Z"`
+}
+
+// validHealthToken reports whether the request carries HEALTH_SECRET, either in
+// the X-Health-Token header or the token query param
+func validHealthToken(c *gin.Context) bool {
+	token := c.GetHeader("X-Health-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(healthSecret)) == 1
+}
+
+// Dependency statuses reported by /health
+const (
+	dependencyOK       = "ok"
+	dependencyDegraded = "degraded"
+	dependencyDown     = "down"
+)
+
+// nutritionixCheckTTL is how long a Nutritionix check result is reused, so
+// frequent probes do not spend the API quota
+const nutritionixCheckTTL = time.Minute
+
+// nutritionixCheck caches the last Nutritionix check
+type nutritionixCheck struct {
+	mu        sync.Mutex
+	status    string
+	checkedAt time.Time
+}
+
+// nutritionixStatus reports whether Nutritionix accepts our credentials, using
+// the cached result while it is fresh. The probe runs without the lock, so a
+// slow Nutritionix does not block other health checks on the cache
+func (h *Handler) nutritionixStatus() string {
+	h.health.mu.Lock()
+	status, checkedAt := h.health.status, h.health.checkedAt
+	h.health.mu.Unlock()
+	if status != "" && time.Since(checkedAt) < nutritionixCheckTTL {
+		return status
+	}
+
+	status = dependencyOK
+	if valid, _ := h.checkCredentials(); !valid {
+		status = dependencyDegraded
+	}
+
+	h.health.mu.Lock()
+	h.health.status = status
+	h.health.checkedAt = time.Now()
+	h.health.mu.Unlock()
+	return status
+}
+
+// databaseStatus pings the SQLite database
+func (h *Handler) databaseStatus(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := h.store.Ping(ctx); err != nil {
+		return dependencyDown
+	}
+	return dependencyOK
+}
+
+// GetHealth godoc
+// @Summary Health check
+// @Description Check if the API and its dependencies are up. The database is critical: when it is down the status is "unhealthy" with 503. A failing Nutritionix check (cached for a minute) only makes the status "degraded", since reads still work. When HEALTH_SECRET is set, only requests carrying the token get the details; others get the minimal status
+// @Tags health
+// @Produce json
+// @Param X-Health-Token header string false "Health secret (required for details when HEALTH_SECRET is set)"
+// @Param token query string false "Health secret, alternative to the header"
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /health [get]
+func (h *Handler) getHealth(c *gin.Context) {
+	if healthSecret != "" && !validHealthToken(c) {
+		h.getLiveness(c)
+		return
+	}
+
+	resp := HealthResponse{
+		Status:      "healthy",
+		Entries:     h.countEntries(),
+		Nutritionix: h.nutritionixStatus(),
+		Dependencies: map[string]string{
+			"database": h.databaseStatus(c.Request.Context()),
+		},
+		Timestamp: time.Now(),
+	}
+	resp.Dependencies["nutritionix"] = resp.Nutritionix
+
+	code := http.StatusOK
+	if resp.Nutritionix != dependencyOK {
+		resp.Status = "degraded"
+	}
+	if resp.Dependencies["database"] != dependencyOK {
+		resp.Status = "unhealthy"
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, resp)
+}
+
+// GetLiveness godoc
+// @Summary Liveness check
+// @Description Minimal unauthenticated check that the API is running
+// @Tags health
+// @Produce json
+// @Success 200 {object} LivenessResponse
+// @Router /health/live [get]
+func (h *Handler) getLiveness(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: "healthy", Timestamp: time.Now()})
+}
+
+// CredentialsResponse reports whether the Nutritionix credentials are accepted
+type CredentialsResponse struct {
+	Valid  bool   `json:"valid" example:"false"`
+	Reason string `json:"reason,omitempty" example:"Nutritionix rejected APP_ID/APP_KEY (status 401)"`
+}
+
+// checkCredentials makes a minimal authenticated call with the Nutritionix
+// client; the returned reason never includes the credentials themselves
+func (h *Handler) checkCredentials() (bool, string) {
+	// Health checks should not wait for the full NUTRITIONIX_TIMEOUT_SECONDS
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := h.nutritionix.Search(ctx, "apple")
+	var statusErr *nutritionix.StatusError
+	switch {
+	case err == nil:
+		return true, ""
+	case errors.Is(err, nutritionix.ErrUnauthorized) && errors.As(err, &statusErr):
+		return false, fmt.Sprintf("Nutritionix rejected APP_ID/APP_KEY (status %d)", statusErr.StatusCode)
+	case errors.As(err, &statusErr):
+		return false, fmt.Sprintf("Unexpected Nutritionix status %d", statusErr.StatusCode)
+	default:
+		return false, "Nutritionix is unreachable"
+	}
+}
+
+// GetCredentialsHealth godoc
+// @Summary Validate Nutritionix credentials
+// @Description Make a minimal authenticated call to Nutritionix and report whether APP_ID/APP_KEY are valid, without exposing them
+// @Tags health
+// @Produce json
+// @Param X-API-Key header string false "API key (required when API_KEY is set)"
+// @Success 200 {object} CredentialsResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} CredentialsResponse
+// @Router /health/credentials [get]
+func (h *Handler) getCredentialsHealth(c *gin.Context) {
+	valid, reason := h.checkCredentials()
+	if !valid {
+		c.JSON(http.StatusBadGateway, CredentialsResponse{Valid: false, Reason: reason})
+		return
+	}
+	c.JSON(http.StatusOK, CredentialsResponse{Valid: true})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+)
+
+// blockingSearch holds every Search call until release is closed
+type blockingSearch struct {
+	stubNutritionix
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (n *blockingSearch) Search(ctx context.Context, query string) (nutritionix.SearchResponse, error) {
+	n.entered <- struct{}{}
+	<-n.release
+	return nutritionix.SearchResponse{}, nil
+}
+
+func TestNutritionixStatusProbesWithoutLock(t *testing.T) {
+	client := &blockingSearch{entered: make(chan struct{}), release: make(chan struct{})}
+	h := New(newFakeStore(), client)
+
+	done := make(chan string, 2)
+	for i := 0; i < 2; i++ {
+		go func() { done <- h.nutritionixStatus() }()
+	}
+	// Both probes must be in flight at once; a lock held across the probe
+	// would keep the second caller waiting for the first
+	for i := 0; i < 2; i++ {
+		select {
+		case <-client.entered:
+		case <-time.After(2 * time.Second):
+			close(client.release)
+			t.Fatalf("only %d of 2 health checks reached Nutritionix", i)
+		}
+	}
+	close(client.release)
+	for i := 0; i < 2; i++ {
+		if status := <-done; status != dependencyOK {
+			t.Errorf("status = %q, want %q", status, dependencyOK)
+		}
+	}
+
+	// A fresh result is reused without probing again
+	if status := h.nutritionixStatus(); status != dependencyOK {
+		t.Errorf("cached status = %q, want %q", status, dependencyOK)
+	}
+}
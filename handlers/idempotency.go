@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyKeyHeader lets a client retry a create without logging the food twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency key bounds; keys are forgotten after idempotencyTTL
+const (
+	maxIdempotencyKeyLen = 255
+	idempotencyTTL       = 24 * time.Hour
+	idempotencySweep     = time.Hour
+)
+
+// idempotentCreate is what a key remembers: the entries its first request
+// created, or nothing yet while that request is still running
+type idempotentCreate struct {
+	ids     []int
+	split   bool
+	pending bool
+	expires time.Time
+}
+
+// idempotencyKeys maps user-scoped idempotency keys to the entries they created
+type idempotencyKeys struct {
+	mu        sync.Mutex
+	keys      map[string]*idempotentCreate
+	lastSweep time.Time
+}
+
+// begin claims key for a new create; when the key is already known it returns
+// its record instead, which is pending if the first request has not finished
+func (k *idempotencyKeys) begin(key string, now time.Time) (idempotentCreate, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.keys = make(map[string]*idempotentCreate)
+	}
+	if now.Sub(k.lastSweep) >= idempotencySweep {
+		for key, rec := range k.keys {
+			if now.After(rec.expires) {
+				delete(k.keys, key)
+			}
+		}
+		k.lastSweep = now
+	}
+
+	if rec, ok := k.keys[key]; ok && !now.After(rec.expires) {
+		return *rec, false
+	}
+	k.keys[key] = &idempotentCreate{pending: true, expires: now.Add(idempotencyTTL)}
+	return idempotentCreate{}, true
+}
+
+// finish records the entries created under key; the TTL runs from now
+func (k *idempotencyKeys) finish(key string, ids []int, split bool, now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys[key] = &idempotentCreate{ids: ids, split: split, expires: now.Add(idempotencyTTL)}
+}
+
+// forget releases key so a failed or vanished create can be retried
+func (k *idempotencyKeys) forget(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.keys, key)
+}
+
+// idempotent replays the result of an earlier create carrying the same
+// Idempotency-Key for the same user with 200, without calling Nutritionix or
+// counting against rate limits; only successful creates are remembered
+func (h *Handler) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(idempotencyKeyHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+		if len(header) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		userID := userIDFrom(c)
+		key := userID + "\x00" + header
+		rec, fresh := h.idempotency.begin(key, time.Now())
+		if !fresh {
+			h.replayCreate(c, key, userID, rec)
+			return
+		}
+
+		finished := false
+		defer func() {
+			if !finished {
+				h.idempotency.forget(key)
+			}
+		}()
+
+		c.Next()
+
+		created, ok := c.Get("created_entries")
+		if c.Writer.Status() != http.StatusCreated || !ok {
+			return
+		}
+		entries := created.([]Entry)
+		ids := make([]int, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.ID
+		}
+		h.idempotency.finish(key, ids, c.GetBool("created_split"), time.Now())
+		finished = true
+	}
+}
+
+// replayCreate answers a repeated create with the entries the key created
+func (h *Handler) replayCreate(c *gin.Context, key, userID string, rec idempotentCreate) {
+	if rec.pending {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+		return
+	}
+
+	entries := make([]Entry, 0, len(rec.ids))
+	for _, id := range rec.ids {
+		entry, ok := h.getUserEntry(id, userID)
+		if !ok {
+			// The entry was deleted since; let the retry create it again
+			h.idempotency.forget(key)
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "The entry created with this Idempotency-Key no longer exists, retry to create it again"})
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Abort()
+	if rec.split {
+		respond(c, http.StatusOK, entries)
+		return
+	}
+	respond(c, http.StatusOK, entries[0])
+}
+
+// rememberCreated hands the entries a create stored to idempotent
+func rememberCreated(c *gin.Context, created []Entry, split bool) {
+	c.Set("created_entries", created)
+	c.Set("created_split", split)
+}
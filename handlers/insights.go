@@ -0,0 +1,993 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoggingTimesResponse represents the per-hour logging distribution
+type LoggingTimesResponse struct {
+	Timezone string  `json:"timezone" example:"Asia/Jakarta"`
+	Total    int     `json:"total" example:"12"`
+	Hours    [24]int `json:"hours"`
+}
+
+// GetLoggingTimes godoc
+// @Summary Get logging time distribution
+// @Description Histogram of how many entries were created in each hour of the day
+// @Tags insights
+// @Produce json
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param tz query string false "IANA timezone used for bucketing (default server local)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} LoggingTimesResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/logging-times [get]
+func (h *Handler) getLoggingTimes(c *gin.Context) {
+	dates, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loc := time.Local
+	if tz := c.Query("tz"); tz != "" {
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+			return
+		}
+	}
+
+	resp := LoggingTimesResponse{Timezone: loc.String()}
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if !dates.Contains(entry.Date) {
+			continue
+		}
+		resp.Hours[entry.CreatedAt.In(loc).Hour()]++
+		resp.Total++
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// MoodByFood represents the average ratings recorded for a food
+type MoodByFood struct {
+	FoodName      string  `json:"food_name" example:"rice"`
+	AverageMood   float64 `json:"average_mood" example:"3.5"`
+	AverageEnergy float64 `json:"average_energy" example:"4"`
+	Entries       int     `json:"entries" example:"2"`
+}
+
+// GetMoodByFood godoc
+// @Summary Get average mood per food
+// @Description Average the mood and energy ratings of entries per food name; unrated entries are skipped
+// @Tags insights
+// @Produce json
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} MoodByFood
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/mood-by-food [get]
+func (h *Handler) getMoodByFood(c *gin.Context) {
+	type acc struct {
+		mood, energy           int
+		moodCount, energyCount int
+		entries                int
+	}
+	byFood := make(map[string]*acc)
+
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if entry.Mood == 0 && entry.Energy == 0 {
+			continue
+		}
+		for _, food := range entry.Nutrients.Foods {
+			a := byFood[food.FoodName]
+			if a == nil {
+				a = &acc{}
+				byFood[food.FoodName] = a
+			}
+			a.entries++
+			if entry.Mood > 0 {
+				a.mood += entry.Mood
+				a.moodCount++
+			}
+			if entry.Energy > 0 {
+				a.energy += entry.Energy
+				a.energyCount++
+			}
+		}
+	}
+
+	result := make([]MoodByFood, 0, len(byFood))
+	for name, a := range byFood {
+		row := MoodByFood{FoodName: name, Entries: a.entries}
+		if a.moodCount > 0 {
+			row.AverageMood = float64(a.mood) / float64(a.moodCount)
+		}
+		if a.energyCount > 0 {
+			row.AverageEnergy = float64(a.energy) / float64(a.energyCount)
+		}
+		result = append(result, row)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].FoodName < result[j].FoodName })
+
+	respond(c, http.StatusOK, result)
+}
+
+// loggedDates returns the distinct valid dates of the entries of userID in ascending order
+func (h *Handler) loggedDates(userID string) []time.Time {
+	seen := make(map[string]bool)
+	var dates []time.Time
+	for _, entry := range h.snapshotUserEntries(userID) {
+		if seen[entry.Date] {
+			continue
+		}
+		seen[entry.Date] = true
+		if d, err := time.Parse(dateLayout, entry.Date); err == nil {
+			dates = append(dates, d)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// LongestGapResponse represents the longest run of days without entries
+type LongestGapResponse struct {
+	Days  int    `json:"days" example:"3"`
+	Start string `json:"start,omitempty" example:"2025-08-12"`
+	End   string `json:"end,omitempty" example:"2025-08-14"`
+}
+
+// GetLongestGap godoc
+// @Summary Get longest logging gap
+// @Description Longest run of consecutive days without entries between the first and last logged dates
+// @Tags insights
+// @Produce json
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} LongestGapResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/longest-gap [get]
+func (h *Handler) getLongestGap(c *gin.Context) {
+	dates := h.loggedDates(userIDFrom(c))
+
+	var resp LongestGapResponse
+	for i := 1; i < len(dates); i++ {
+		missing := int(dates[i].Sub(dates[i-1]).Hours()/24) - 1
+		if missing > resp.Days {
+			resp = LongestGapResponse{
+				Days:  missing,
+				Start: dates[i-1].AddDate(0, 0, 1).Format(dateLayout),
+				End:   dates[i].AddDate(0, 0, -1).Format(dateLayout),
+			}
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// kcalPerKg is the energy commonly attributed to one kilogram of body fat
+const kcalPerKg = 7700
+
+// WeeklyBalanceResponse represents the energy balance of an ISO week
+type WeeklyBalanceResponse struct {
+	Week                string  `json:"week" example:"2025-W33"`
+	Start               string  `json:"start" example:"2025-08-11"`
+	End                 string  `json:"end" example:"2025-08-17"`
+	MaintenanceCalories float64 `json:"maintenance_calories" example:"2300"`
+	IntakeCalories      float64 `json:"intake_calories" example:"14700"`
+	BalanceCalories     float64 `json:"balance_calories" example:"-1400"`
+	WeightChangeKg      float64 `json:"weight_change_kg" example:"-0.18"`
+}
+
+// GetWeeklyBalance godoc
+// @Summary Get weekly calorie balance
+// @Description Total surplus/deficit against maintenance for an ISO week and the implied weight change (7700 kcal per kg); days without entries count as zero intake
+// @Tags insights
+// @Produce json
+// @Param week query string false "ISO week, e.g. 2025-W33 (default current week)"
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} WeeklyBalanceResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /insights/weekly-balance [get]
+func (h *Handler) getWeeklyBalance(c *gin.Context) {
+	week := c.Query("week")
+	if week == "" {
+		y, w := time.Now().ISOWeek()
+		week = fmt.Sprintf("%04d-W%02d", y, w)
+	}
+	monday, err := parseISOWeek(week)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok || g.Maintenance <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Maintenance calories not configured in goals"})
+		return
+	}
+
+	days := h.dailyTotals(userIDFrom(c))
+	resp := WeeklyBalanceResponse{
+		Week:                week,
+		Start:               monday.Format(dateLayout),
+		End:                 monday.AddDate(0, 0, 6).Format(dateLayout),
+		MaintenanceCalories: g.Maintenance,
+	}
+	for i := 0; i < 7; i++ {
+		resp.IntakeCalories += days[monday.AddDate(0, 0, i).Format(dateLayout)].Calories
+	}
+	resp.BalanceCalories = resp.IntakeCalories - 7*g.Maintenance
+	resp.WeightChangeKg = resp.BalanceCalories / kcalPerKg
+
+	respond(c, http.StatusOK, resp)
+}
+
+// DuplicateGroup represents entries sharing the same normalized query and date
+type DuplicateGroup struct {
+	Date     string `json:"date" example:"2025-08-11"`
+	Query    string `json:"query" example:"1 cup rice"`
+	EntryIDs []int  `json:"entry_ids" example:"3,5"`
+}
+
+// GetDuplicates godoc
+// @Summary Find duplicate entries
+// @Description Group entries that share the same normalized query and date
+// @Tags insights
+// @Produce json
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} DuplicateGroup
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/duplicates [get]
+func (h *Handler) getDuplicates(c *gin.Context) {
+	type key struct{ date, query string }
+	groups := make(map[key][]int)
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		k := key{entry.Date, entry.NormalizedQuery}
+		groups[k] = append(groups[k], entry.ID)
+	}
+
+	result := []DuplicateGroup{}
+	for k, ids := range groups {
+		if len(ids) > 1 {
+			result = append(result, DuplicateGroup{Date: k.date, Query: k.query, EntryIDs: ids})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		return result[i].Query < result[j].Query
+	})
+
+	respond(c, http.StatusOK, result)
+}
+
+// nutrient describes how to read a nutrient from a food and its display unit
+type nutrient struct {
+	Unit  string
+	Value func(Food) float64
+}
+
+// nutrients are the nutrients that can be ranked by name
+var nutrients = map[string]nutrient{
+	"calories": {"kcal", func(f Food) float64 { return f.NFCalories }},
+	"protein":  {"g", func(f Food) float64 { return f.NFProtein }},
+	"carbs":    {"g", func(f Food) float64 { return f.NFTotalCarbs }},
+	"fat":      {"g", func(f Food) float64 { return f.NFTotalFat }},
+	"sugars":   {"g", func(f Food) float64 { return f.NFSugars }},
+	"sodium":   {"mg", func(f Food) float64 { return f.NFSodium }},
+}
+
+// Contributor represents a food's contribution to a nutrient
+type Contributor struct {
+	EntryID  int     `json:"entry_id" example:"3"`
+	FoodName string  `json:"food_name" example:"soy sauce"`
+	Amount   float64 `json:"amount" example:"879"`
+	Unit     string  `json:"unit" example:"mg"`
+}
+
+// GetContributors godoc
+// @Summary Get top nutrient contributors
+// @Description Foods that contributed most to a nutrient on a day, ranked by amount
+// @Tags insights
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param nutrient query string true "Nutrient" Enums(calories, protein, carbs, fat, sugars, sodium)
+// @Param top query int false "Number of foods to return (default 5, max 50)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} Contributor
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/contributors [get]
+func (h *Handler) getContributors(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	n, ok := nutrients[c.Query("nutrient")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown nutrient, expected one of calories, protein, carbs, fat, sugars, sodium"})
+		return
+	}
+	top, err := strconv.Atoi(c.DefaultQuery("top", "5"))
+	if err != nil || top < 1 || top > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "top must be between 1 and 50"})
+		return
+	}
+
+	result := []Contributor{}
+	for _, entry := range h.entriesOn(userIDFrom(c), date) {
+		for _, food := range entry.Nutrients.Foods {
+			result = append(result, Contributor{
+				EntryID:  entry.ID,
+				FoodName: food.FoodName,
+				Amount:   n.Value(food),
+				Unit:     n.Unit,
+			})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Amount > result[j].Amount })
+	if len(result) > top {
+		result = result[:top]
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// GoalForecastResponse represents the projected end-of-day intake against the goal
+type GoalForecastResponse struct {
+	Date                string  `json:"date" example:"2025-08-11"`
+	GoalCalories        float64 `json:"goal_calories" example:"2000"`
+	LoggedCalories      float64 `json:"logged_calories" example:"1200"`
+	AverageRemaining    float64 `json:"average_remaining_calories" example:"650"`
+	ProjectedCalories   float64 `json:"projected_calories" example:"1850"`
+	ProbabilityUnderPct float64 `json:"probability_under_goal_pct" example:"70"`
+	HistoryDays         int     `json:"history_days" example:"10"`
+}
+
+// minutesOfDay returns the clock time of t in minutes since local midnight
+func minutesOfDay(t time.Time) int {
+	t = t.In(time.Local)
+	return t.Hour()*60 + t.Minute()
+}
+
+// forecastCutoff returns the minutes of date already elapsed at now: the
+// current time of day for today, the full day for past dates and none for
+// future ones
+func forecastCutoff(date string, now time.Time) int {
+	today := now.In(time.Local).Format("2006-01-02")
+	switch {
+	case date < today:
+		return 24 * 60
+	case date > today:
+		return 0
+	}
+	return minutesOfDay(now)
+}
+
+// GetGoalForecast godoc
+// @Summary Forecast goal adherence for a day
+// @Description Project the end-of-day calories from what is logged so far plus the average intake logged after the current time of day on earlier days (past dates are complete, future dates have nothing elapsed), and estimate the probability of staying under the calorie goal as the share of earlier days that would have
+// @Tags insights
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} GoalForecastResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /insights/goal-forecast [get]
+func (h *Handler) getGoalForecast(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No goals set"})
+		return
+	}
+
+	cutoff := forecastCutoff(date, time.Now())
+	logged := 0.0
+	remaining := make(map[string]float64) // earlier date -> calories logged after the cutoff
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		switch {
+		case entry.Date == date:
+			logged += entryTotals(entry).Calories
+		case entry.Date < date:
+			kcal := 0.0
+			if minutesOfDay(entry.CreatedAt) > cutoff {
+				kcal = entryTotals(entry).Calories
+			}
+			remaining[entry.Date] += kcal
+		}
+	}
+
+	resp := GoalForecastResponse{
+		Date:           date,
+		GoalCalories:   g.Calories,
+		LoggedCalories: logged,
+		HistoryDays:    len(remaining),
+	}
+	under := 0
+	for _, kcal := range remaining {
+		resp.AverageRemaining += kcal
+		if logged+kcal <= g.Calories {
+			under++
+		}
+	}
+	if resp.HistoryDays > 0 {
+		resp.AverageRemaining /= float64(resp.HistoryDays)
+		resp.ProbabilityUnderPct = 100 * float64(under) / float64(resp.HistoryDays)
+	} else if logged <= g.Calories {
+		resp.ProbabilityUnderPct = 100
+	}
+	resp.ProjectedCalories = logged + resp.AverageRemaining
+
+	respond(c, http.StatusOK, resp)
+}
+
+// maxSparklineDays caps the length of the sparkline series
+const maxSparklineDays = 90
+
+// GetSparkline godoc
+// @Summary Get a sparkline calorie series
+// @Description Daily calorie totals for the last N days ending today, oldest first; days without entries are zero
+// @Tags insights
+// @Produce json
+// @Param days query int false "Number of days (default 7, max 90)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} number
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/sparkline [get]
+func (h *Handler) getSparkline(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > maxSparklineDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("days must be between 1 and %d", maxSparklineDays)})
+		return
+	}
+
+	totals := h.dailyTotals(userIDFrom(c))
+	today := time.Now()
+	series := make([]float64, days)
+	for i := range series {
+		date := today.AddDate(0, 0, i-days+1).Format(dateLayout)
+		series[i] = totals[date].Calories
+	}
+
+	respond(c, http.StatusOK, series)
+}
+
+// DiningBucket represents the entries of one dining location
+type DiningBucket struct {
+	Entries     int     `json:"entries" example:"8"`
+	EntriesPct  float64 `json:"entries_pct" example:"66.67"`
+	Calories    float64 `json:"calories" example:"4200"`
+	CaloriesPct float64 `json:"calories_pct" example:"58.3"`
+}
+
+// DiningSplitResponse represents home-cooked versus eating-out entries
+type DiningSplitResponse struct {
+	Home    DiningBucket `json:"home"`
+	Out     DiningBucket `json:"out"`
+	Unknown DiningBucket `json:"unknown"`
+}
+
+// GetDiningSplit godoc
+// @Summary Get home-cooked vs eating-out split
+// @Description Count and calorie share of entries tagged "home" or "out" over a date range; entries with neither tag (or both) go in the unknown bucket, and percentages sum to 100
+// @Tags insights
+// @Produce json
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} DiningSplitResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/dining-split [get]
+func (h *Handler) getDiningSplit(c *gin.Context) {
+	dates, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var resp DiningSplitResponse
+	var total DiningBucket
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if !dates.Contains(entry.Date) {
+			continue
+		}
+		var home, out bool
+		for _, tag := range entry.Tags {
+			home = home || tag == "home"
+			out = out || tag == "out"
+		}
+
+		bucket := &resp.Unknown
+		switch {
+		case home && !out:
+			bucket = &resp.Home
+		case out && !home:
+			bucket = &resp.Out
+		}
+		kcal := entryTotals(entry).Calories
+		bucket.Entries++
+		bucket.Calories += kcal
+		total.Entries++
+		total.Calories += kcal
+	}
+
+	for _, b := range []*DiningBucket{&resp.Home, &resp.Out, &resp.Unknown} {
+		if total.Entries > 0 {
+			b.EntriesPct = 100 * float64(b.Entries) / float64(total.Entries)
+		}
+		if total.Calories > 0 {
+			b.CaloriesPct = 100 * b.Calories / total.Calories
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// varietyTarget is the weekly count of distinct foods that scores 100, after
+// the common "30 different foods a week" guideline
+const varietyTarget = 30
+
+// VarietyResponse represents the dietary variety of an ISO week
+type VarietyResponse struct {
+	Week          string   `json:"week" example:"2025-W33"`
+	Start         string   `json:"start" example:"2025-08-11"`
+	End           string   `json:"end" example:"2025-08-17"`
+	DistinctFoods int      `json:"distinct_foods" example:"18"`
+	Score         float64  `json:"score" example:"60"`
+	Foods         []string `json:"foods" example:"banana,rice"`
+}
+
+// GetVariety godoc
+// @Summary Get weekly variety score
+// @Description Count the distinct food names logged in an ISO week; the score is the share of 30 distinct foods, capped at 100
+// @Tags insights
+// @Produce json
+// @Param week query string false "ISO week, e.g. 2025-W33 (default current week)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} VarietyResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/variety [get]
+func (h *Handler) getVariety(c *gin.Context) {
+	week := c.Query("week")
+	if week == "" {
+		y, w := time.Now().ISOWeek()
+		week = fmt.Sprintf("%04d-W%02d", y, w)
+	}
+	monday, err := parseISOWeek(week)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := VarietyResponse{
+		Week:  week,
+		Start: monday.Format(dateLayout),
+		End:   monday.AddDate(0, 0, 6).Format(dateLayout),
+		Foods: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if entry.Date < resp.Start || entry.Date > resp.End {
+			continue
+		}
+		for _, food := range entry.Nutrients.Foods {
+			name := normalizeQuery(food.FoodName)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				resp.Foods = append(resp.Foods, name)
+			}
+		}
+	}
+	sort.Strings(resp.Foods)
+	resp.DistinctFoods = len(resp.Foods)
+	resp.Score = roundAmount(math.Min(100*float64(resp.DistinctFoods)/varietyTarget, 100))
+
+	respond(c, http.StatusOK, resp)
+}
+
+// RollingPoint represents the trailing average calories ending on a date
+type RollingPoint struct {
+	Date            string  `json:"date" example:"2025-08-11"`
+	Calories        float64 `json:"calories" example:"2100"`
+	AverageCalories float64 `json:"average_calories" example:"1985.3"`
+	WindowDays      int     `json:"window_days" example:"7"`
+}
+
+// GetRollingAverage godoc
+// @Summary Get rolling average calories
+// @Description For each of the last N days, the average daily calories over the trailing window; the window reaches back before the range, and days before the first logged date are left out so the warm-up is not dragged toward zero
+// @Tags insights
+// @Produce json
+// @Param window query int false "Window size in days (default 7, max 30)"
+// @Param days query int false "Number of days to return, ending today (default 30, max 365)"
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} RollingPoint
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/rolling-average [get]
+func (h *Handler) getRollingAverage(c *gin.Context) {
+	window, err := strconv.Atoi(c.DefaultQuery("window", "7"))
+	if err != nil || window < 1 || window > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "window must be between 1 and 30"})
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+
+	totals := h.dailyTotals(userIDFrom(c))
+	first := ""
+	if logged := h.loggedDates(userIDFrom(c)); len(logged) > 0 {
+		first = logged[0].Format(dateLayout)
+	}
+
+	today := time.Now()
+	result := make([]RollingPoint, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i)
+		point := RollingPoint{Date: day.Format(dateLayout), Calories: totals[day.Format(dateLayout)].Calories}
+
+		var sum float64
+		for j := 0; j < window; j++ {
+			date := day.AddDate(0, 0, -j).Format(dateLayout)
+			if first == "" || date < first {
+				break
+			}
+			sum += totals[date].Calories
+			point.WindowDays++
+		}
+		if point.WindowDays > 0 {
+			point.AverageCalories = roundAmount(sum / float64(point.WindowDays))
+		}
+		result = append(result, point)
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// ConsistencyResponse represents how steady daily calorie intake was
+type ConsistencyResponse struct {
+	Days                   int     `json:"days" example:"30"`
+	Missing                string  `json:"missing" example:"skip"`
+	SampleDays             int     `json:"sample_days" example:"24"`
+	MeanCalories           float64 `json:"mean_calories" example:"2050.4"`
+	StdDevCalories         float64 `json:"stddev_calories" example:"310.2"`
+	CoefficientOfVariation float64 `json:"coefficient_of_variation" example:"0.15"`
+}
+
+// GetConsistency godoc
+// @Summary Get calorie intake consistency
+// @Description Mean, standard deviation and coefficient of variation of daily calorie totals over the last N days; days without entries are skipped or counted as zero
+// @Tags insights
+// @Produce json
+// @Param days query int false "Number of days, ending today (default 30, max 365)"
+// @Param missing query string false "How to treat days without entries (default skip)" Enums(skip, zero)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} ConsistencyResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/consistency [get]
+func (h *Handler) getConsistency(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 365"})
+		return
+	}
+	missing := c.DefaultQuery("missing", "skip")
+	if missing != "skip" && missing != "zero" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing must be skip or zero"})
+		return
+	}
+
+	totals := h.dailyTotals(userIDFrom(c))
+	today := time.Now()
+	var samples []float64
+	for i := 0; i < days; i++ {
+		t, logged := totals[today.AddDate(0, 0, -i).Format(dateLayout)]
+		if !logged && missing == "skip" {
+			continue
+		}
+		samples = append(samples, t.Calories)
+	}
+
+	resp := ConsistencyResponse{Days: days, Missing: missing, SampleDays: len(samples)}
+	if len(samples) > 0 {
+		var sum float64
+		for _, v := range samples {
+			sum += v
+		}
+		mean := sum / float64(len(samples))
+
+		var sq float64
+		for _, v := range samples {
+			sq += (v - mean) * (v - mean)
+		}
+		stddev := math.Sqrt(sq / float64(len(samples)))
+
+		resp.MeanCalories = roundAmount(mean)
+		resp.StdDevCalories = roundAmount(stddev)
+		if mean > 0 {
+			resp.CoefficientOfVariation = roundAmount(stddev / mean)
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// DayExtreme represents a day's totals and how far its calories are from the goal
+type DayExtreme struct {
+	Date         string  `json:"date" example:"2025-08-11"`
+	Totals       Totals  `json:"totals"`
+	DiffCalories float64 `json:"diff_calories" example:"-120.5"`
+}
+
+// ExtremesResponse represents the best and worst days of a month by calorie goal adherence
+type ExtremesResponse struct {
+	Month        string      `json:"month" example:"2025-08"`
+	GoalCalories float64     `json:"goal_calories" example:"2000"`
+	Best         *DayExtreme `json:"best"`
+	Worst        *DayExtreme `json:"worst"`
+}
+
+// GetExtremes godoc
+// @Summary Get best and worst days of a month
+// @Description Return the logged day closest to and furthest from the calorie goal within a month, with their totals; diff_calories is the day's calories minus the goal, and best and worst are null when the month has no entries
+// @Tags insights
+// @Produce json
+// @Param month query string true "Month (YYYY-MM)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} ExtremesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /insights/extremes [get]
+func (h *Handler) getExtremes(c *gin.Context) {
+	month := c.Query("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "month must be in YYYY-MM format"})
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No goals set"})
+		return
+	}
+
+	days := h.dailyTotals(userIDFrom(c))
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		if strings.HasPrefix(date, month+"-") {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	resp := ExtremesResponse{Month: month, GoalCalories: g.Calories}
+	for _, date := range dates {
+		day := &DayExtreme{
+			Date:         date,
+			Totals:       days[date].Rounded(),
+			DiffCalories: roundAmount(days[date].Calories - g.Calories),
+		}
+		if resp.Best == nil || math.Abs(day.DiffCalories) < math.Abs(resp.Best.DiffCalories) {
+			resp.Best = day
+		}
+		if resp.Worst == nil || math.Abs(day.DiffCalories) > math.Abs(resp.Worst.DiffCalories) {
+			resp.Worst = day
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// fiberRecommendedG is the default daily fiber recommendation, FIBER_RECOMMENDED_G
+var fiberRecommendedG = 25.0
+
+// fiberRecommendedBySex are the Adequate Intake values for adults under 50
+var fiberRecommendedBySex = map[string]float64{
+	"female": 25,
+	"male":   38,
+}
+
+// FiberResponse represents fiber intake against the daily recommendation
+type FiberResponse struct {
+	Date         string  `json:"date,omitempty" example:"2025-08-11"`
+	From         string  `json:"from,omitempty" example:"2025-08-01"`
+	To           string  `json:"to,omitempty" example:"2025-08-07"`
+	Days         int     `json:"days" example:"7"`
+	FiberG       float64 `json:"fiber_g" example:"18.4"`
+	RecommendedG float64 `json:"recommended_g" example:"25"`
+	GapG         float64 `json:"gap_g" example:"6.6"`
+	Pct          float64 `json:"pct_of_recommended" example:"73.6"`
+}
+
+// GetFiber godoc
+// @Summary Get fiber intake adequacy
+// @Description Compare fiber intake with the daily recommendation (FIBER_RECOMMENDED_G, or by sex); with from/to the fiber is averaged over the logged days of the range
+// @Tags insights
+// @Produce json
+// @Param date query string false "Date" format(date)
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param sex query string false "Use the recommendation for this sex" Enums(female, male)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} FiberResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/fiber [get]
+func (h *Handler) getFiber(c *gin.Context) {
+	if c.Query("date") == "" && c.Query("from") == "" && c.Query("to") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date or from/to is required"})
+		return
+	}
+	dates, err := parseEntryDates(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recommended := fiberRecommendedG
+	if sex := c.Query("sex"); sex != "" {
+		var ok bool
+		if recommended, ok = fiberRecommendedBySex[sex]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sex must be female or male"})
+			return
+		}
+	}
+
+	resp := FiberResponse{RecommendedG: recommended}
+	if date := c.Query("date"); date != "" {
+		resp.Date = date
+	} else {
+		resp.From, resp.To = dates.From, dates.To
+	}
+
+	var total float64
+	for date, t := range h.dailyTotals(userIDFrom(c)) {
+		if dates.Contains(date) {
+			resp.Days++
+			total += t.Fiber
+		}
+	}
+	if resp.Days > 0 {
+		resp.FiberG = total / float64(resp.Days)
+	}
+	resp.GapG = roundAmount(math.Max(recommended-resp.FiberG, 0))
+	resp.Pct = roundAmount(100 * resp.FiberG / recommended)
+	resp.FiberG = roundAmount(resp.FiberG)
+
+	respond(c, http.StatusOK, resp)
+}
+
+// DayTypeAverage represents the average daily intake of weekdays or weekend days
+type DayTypeAverage struct {
+	Days    int    `json:"days" example:"5"`
+	Average Totals `json:"average"`
+}
+
+// WeekendDifference represents how much higher (positive) or lower the weekend
+// average is than the weekday average, in percent; a field is null when the
+// weekday average is zero
+type WeekendDifference struct {
+	Calories *float64 `json:"calories_pct" example:"18.5"`
+	Protein  *float64 `json:"protein_pct" example:"-4.2"`
+	Carbs    *float64 `json:"carbs_pct" example:"22.1"`
+	Fat      *float64 `json:"fat_pct" example:"30.7"`
+}
+
+// WeekendEffectResponse represents weekend versus weekday eating over a range
+type WeekendEffectResponse struct {
+	From          string             `json:"from,omitempty" example:"2025-08-01"`
+	To            string             `json:"to,omitempty" example:"2025-08-31"`
+	Weekday       DayTypeAverage     `json:"weekday"`
+	Weekend       DayTypeAverage     `json:"weekend"`
+	DifferencePct *WeekendDifference `json:"difference_pct"`
+}
+
+// pctDifference returns the change from base to value in percent, or nil when base is zero
+func pctDifference(value, base float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	pct := roundAmount(100 * (value - base) / base)
+	return &pct
+}
+
+// GetWeekendEffect godoc
+// @Summary Compare weekend and weekday intake
+// @Description Average the daily calories and macros of logged Saturdays and Sundays against logged weekdays over a date range, with the percentage difference of the weekend; difference_pct is null unless both groups have logged days
+// @Tags insights
+// @Produce json
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} WeekendEffectResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/weekend-effect [get]
+func (h *Handler) getWeekendEffect(c *gin.Context) {
+	dates, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var weekday, weekend Totals
+	resp := WeekendEffectResponse{From: dates.From, To: dates.To}
+	for date, t := range h.dailyTotals(userIDFrom(c)) {
+		if !dates.Contains(date) {
+			continue
+		}
+		d, err := time.Parse(dateLayout, date)
+		if err != nil {
+			continue
+		}
+		sum, group := &weekday, &resp.Weekday
+		if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+			sum, group = &weekend, &resp.Weekend
+		}
+		sum.Calories += t.Calories
+		sum.Protein += t.Protein
+		sum.Carbs += t.Carbs
+		sum.Fat += t.Fat
+		group.Days++
+	}
+
+	for _, g := range []struct {
+		sum   Totals
+		group *DayTypeAverage
+	}{{weekday, &resp.Weekday}, {weekend, &resp.Weekend}} {
+		if n := float64(g.group.Days); n > 0 {
+			g.group.Average = Totals{
+				Calories: g.sum.Calories / n,
+				Protein:  g.sum.Protein / n,
+				Carbs:    g.sum.Carbs / n,
+				Fat:      g.sum.Fat / n,
+			}.Rounded()
+		}
+	}
+
+	if resp.Weekday.Days > 0 && resp.Weekend.Days > 0 {
+		wd, we := resp.Weekday.Average, resp.Weekend.Average
+		resp.DifferencePct = &WeekendDifference{
+			Calories: pctDifference(we.Calories, wd.Calories),
+			Protein:  pctDifference(we.Protein, wd.Protein),
+			Carbs:    pctDifference(we.Carbs, wd.Carbs),
+			Fat:      pctDifference(we.Fat, wd.Fat),
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecastCutoff(t *testing.T) {
+	now := time.Date(2025, 8, 11, 14, 30, 0, 0, time.Local)
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"2025-08-11", 14*60 + 30},
+		{"2025-08-10", 24 * 60},
+		{"2024-12-31", 24 * 60},
+		{"2025-08-12", 0},
+	}
+	for _, tt := range tests {
+		if got := forecastCutoff(tt.date, now); got != tt.want {
+			t.Errorf("forecastCutoff(%q) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader carries the correlation ID of a request in both directions
+const requestIDHeader = "X-Request-ID"
+
+// requestIDPattern bounds client-supplied request IDs so they are safe to log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// requestIDFrom returns the request ID stored in ctx, or "" outside a request
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// loggerFrom returns the default logger tagged with the request ID of ctx
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if id := requestIDFrom(ctx); id != "" {
+		return slog.Default().With("request_id", id)
+	}
+	return slog.Default()
+}
+
+// logNutritionixError logs a failed Nutritionix call with the request ID so it
+// can be traced back to the inbound request
+func logNutritionixError(ctx context.Context, err error) {
+	loggerFrom(ctx).Error("Nutritionix API error", "error", err)
+}
+
+// requestID assigns every request an ID, reusing a well-formed X-Request-ID
+// from the client, and stores it in the request context and response header
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestLogger writes one structured log line per request
+func requestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		slog.LogAttrs(c.Request.Context(), slog.LevelInfo, "request",
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", c.Writer.Status()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("request_id", requestIDFrom(c.Request.Context())),
+		)
+	}
+}
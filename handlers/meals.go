@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fierda/go_nutrition/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Meal categories
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+// mainMeals are the main meals of the day in order
+var mainMeals = []string{MealBreakfast, MealLunch, MealDinner}
+
+// allMeals are every meal category in display order
+var allMeals = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// inferMeal guesses the meal category from the time an entry was logged
+func inferMeal(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 4 && h < 11:
+		return MealBreakfast
+	case h >= 11 && h < 15:
+		return MealLunch
+	case h >= 17 && h < 22:
+		return MealDinner
+	default:
+		return MealSnack
+	}
+}
+
+// BackfillResponse represents the result of a meal backfill
+type BackfillResponse struct {
+	Updated int `json:"updated" example:"12"`
+}
+
+// BackfillMeals godoc
+// @Summary Backfill missing meal categories
+// @Description Assign a meal to every entry without one, inferred from the hour it was created
+// @Tags entries
+// @Produce json
+// @Param tz query string false "IANA timezone used to read the creation hour (default server local)"
+// @Param X-API-Key header string false "API key (required when API_KEY is set)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} BackfillResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/backfill-meals [post]
+func (h *Handler) backfillMeals(c *gin.Context) {
+	loc := time.Local
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone"})
+			return
+		}
+	}
+
+	var resp BackfillResponse
+	userID := userIDFrom(c)
+	err := h.updateEntries(func(current map[int]Entry) ([]Entry, []int, error) {
+		var updated []Entry
+		for _, entry := range current {
+			if entry.UserID != userID || mealOf(entry) != mealUncategorized {
+				continue
+			}
+			entry.Meal = inferMeal(entry.CreatedAt.In(loc))
+			entry.UpdatedAt = time.Now()
+			updated = append(updated, entry)
+		}
+		resp.Updated = len(updated)
+		return updated, nil, nil
+	})
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to backfill meals"})
+		return
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// MealTarget represents the targets for one of the remaining meals
+type MealTarget struct {
+	Meal string `json:"meal" example:"dinner"`
+	Totals
+}
+
+// RemainingPlanResponse represents how to split the remaining budget of a day
+type RemainingPlanResponse struct {
+	Date      string       `json:"date" example:"2025-08-11"`
+	Remaining Totals       `json:"remaining"`
+	Meals     []MealTarget `json:"meals"`
+}
+
+// unloggedMainMeals returns the main meals without any entry on the day
+func unloggedMainMeals(day []Entry) []string {
+	logged := make(map[string]bool)
+	for _, entry := range day {
+		logged[mealOf(entry)] = true
+	}
+
+	var meals []string
+	for _, meal := range mainMeals {
+		if !logged[meal] {
+			meals = append(meals, meal)
+		}
+	}
+	return meals
+}
+
+// remainingMealLabels names the next n meals, starting with main meals not yet logged
+func remainingMealLabels(day []Entry, n int) []string {
+	labels := unloggedMainMeals(day)
+	if len(labels) > n {
+		labels = labels[:n]
+	}
+	for len(labels) < n {
+		labels = append(labels, MealSnack)
+	}
+	return labels
+}
+
+// parseWeights reads n positive comma-separated weights, defaulting to equal shares
+func parseWeights(raw string, n int) ([]float64, error) {
+	weights := make([]float64, n)
+	if raw == "" {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("weights must have %d values", n)
+	}
+	for i, p := range parts {
+		w, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || w <= 0 {
+			return nil, fmt.Errorf("weights must be positive numbers")
+		}
+		weights[i] = w
+	}
+	return weights, nil
+}
+
+// GetRemainingPlan godoc
+// @Summary Plan the remaining meals
+// @Description Split the day's remaining calorie and macro budget across the remaining meals, equally or by weight
+// @Tags plan
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param meals query int false "Number of remaining meals (default: main meals not logged yet)"
+// @Param weights query string false "Comma-separated relative weights per meal, e.g. 1,2"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} RemainingPlanResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /plan/remaining [get]
+func (h *Handler) getRemainingPlan(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No goals set"})
+		return
+	}
+
+	day := h.entriesOn(userIDFrom(c), date)
+	var eaten Totals
+	for _, entry := range day {
+		eaten.AddEntry(entry)
+	}
+
+	n := len(unloggedMainMeals(day))
+	if raw := c.Query("meals"); raw != "" {
+		var err error
+		if n, err = strconv.Atoi(raw); err != nil || n < 1 || n > 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "meals must be between 1 and 10"})
+			return
+		}
+	}
+	if n == 0 {
+		n = 1
+	}
+	weights, err := parseWeights(c.Query("weights"), n)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := RemainingPlanResponse{
+		Date: date,
+		Remaining: Totals{
+			Calories: math.Max(g.Calories-eaten.Calories, 0),
+			Protein:  math.Max(g.Protein-eaten.Protein, 0),
+			Carbs:    math.Max(g.Carbs-eaten.Carbs, 0),
+			Fat:      math.Max(g.Fat-eaten.Fat, 0),
+		},
+	}
+	var totalWeight float64
+	for _, w := range weights {
+		totalWeight += w
+	}
+	for i, meal := range remainingMealLabels(day, n) {
+		share := weights[i] / totalWeight
+		resp.Meals = append(resp.Meals, MealTarget{
+			Meal: meal,
+			Totals: Totals{
+				Calories: resp.Remaining.Calories * share,
+				Protein:  resp.Remaining.Protein * share,
+				Carbs:    resp.Remaining.Carbs * share,
+				Fat:      resp.Remaining.Fat * share,
+			},
+		})
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// densityScore rates how nutrient-dense foods are as grams of protein and
+// fiber per 100 kcal; foods without calories score zero
+func densityScore(foods []Food) float64 {
+	var calories, grams float64
+	for _, food := range foods {
+		calories += food.NFCalories
+		grams += food.NFProtein + food.NFDietaryFiber
+	}
+	if calories <= 0 {
+		return 0
+	}
+	return grams / calories * 100
+}
+
+// MealScore represents the density score of one meal
+type MealScore struct {
+	Meal    string  `json:"meal" example:"lunch"`
+	Entries int     `json:"entries" example:"2"`
+	Score   float64 `json:"score" example:"6.4"`
+}
+
+// BestMealResponse represents the most nutrient-dense meal of a day
+type BestMealResponse struct {
+	Date     string      `json:"date" example:"2025-08-11"`
+	BestMeal string      `json:"best_meal,omitempty" example:"lunch"`
+	Meals    []MealScore `json:"meals"`
+}
+
+// GetBestMeal godoc
+// @Summary Get the most nutrient-dense meal
+// @Description Score each categorized meal of a day by grams of protein and fiber per 100 kcal and return the best one; uncategorized entries are ignored
+// @Tags insights
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} BestMealResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/best-meal [get]
+func (h *Handler) getBestMeal(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+
+	foods := make(map[string][]Food)
+	entries := make(map[string]int)
+	for _, entry := range h.entriesOn(userIDFrom(c), date) {
+		if mealOf(entry) == mealUncategorized {
+			continue
+		}
+		foods[entry.Meal] = append(foods[entry.Meal], entry.Nutrients.Foods...)
+		entries[entry.Meal]++
+	}
+
+	resp := BestMealResponse{Date: date, Meals: []MealScore{}}
+	best := -1.0
+	for _, meal := range allMeals {
+		if entries[meal] == 0 {
+			continue
+		}
+		score := MealScore{Meal: meal, Entries: entries[meal], Score: densityScore(foods[meal])}
+		resp.Meals = append(resp.Meals, score)
+		if score.Score > best {
+			best = score.Score
+			resp.BestMeal = meal
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// mealUncategorized is the meal of entries logged without a meal category
+const mealUncategorized = store.MealUncategorized
+
+// mealCategories are every meal category followed by mealUncategorized
+var mealCategories = append(append([]string(nil), allMeals...), mealUncategorized)
+
+// mealOf returns the meal category of an entry, mealUncategorized when it has
+// none; entries built before meals were always stored may still be empty
+func mealOf(entry Entry) string {
+	if entry.Meal == "" {
+		return mealUncategorized
+	}
+	return entry.Meal
+}
+
+// parseMealFilter reads the optional meal query param; uncategorized selects
+// entries without a meal
+func parseMealFilter(c *gin.Context) (string, error) {
+	meal := c.Query("meal")
+	if meal == "" || slices.Contains(mealCategories, meal) {
+		return meal, nil
+	}
+	return "", fmt.Errorf("meal must be one of %s", strings.Join(mealCategories, ", "))
+}
+
+// MealShare represents the calories of one meal category and their share of the total
+type MealShare struct {
+	Meal     string  `json:"meal" example:"dinner"`
+	Entries  int     `json:"entries" example:"12"`
+	Calories float64 `json:"calories" example:"8400"`
+	Pct      float64 `json:"pct" example:"38.5"`
+}
+
+// MealDistributionResponse represents how calories are spread across meals
+type MealDistributionResponse struct {
+	TotalCalories float64     `json:"total_calories" example:"21800"`
+	Meals         []MealShare `json:"meals"`
+}
+
+// GetMealDistribution godoc
+// @Summary Get calorie distribution across meals
+// @Description Share of total calories per meal category over a date range, plus an uncategorized bucket for entries without a meal; an empty range returns zeros
+// @Tags insights
+// @Produce json
+// @Param from query string false "Start date (inclusive)" format(date)
+// @Param to query string false "End date (inclusive)" format(date)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} MealDistributionResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /insights/meal-distribution [get]
+func (h *Handler) getMealDistribution(c *gin.Context) {
+	dates, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	index := make(map[string]int, len(mealCategories))
+	resp := MealDistributionResponse{Meals: make([]MealShare, len(mealCategories))}
+	for i, meal := range mealCategories {
+		index[meal] = i
+		resp.Meals[i].Meal = meal
+	}
+
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if !dates.Contains(entry.Date) {
+			continue
+		}
+		i := index[mealOf(entry)]
+		kcal := entryTotals(entry).Calories
+		resp.Meals[i].Entries++
+		resp.Meals[i].Calories += kcal
+		resp.TotalCalories += kcal
+	}
+
+	for i := range resp.Meals {
+		if resp.TotalCalories > 0 {
+			resp.Meals[i].Pct = roundAmount(100 * resp.Meals[i].Calories / resp.TotalCalories)
+		}
+		resp.Meals[i].Calories = roundAmount(resp.Meals[i].Calories)
+	}
+	resp.TotalCalories = roundAmount(resp.TotalCalories)
+
+	respond(c, http.StatusOK, resp)
+}
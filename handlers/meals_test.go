@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestEntryMealDefaultsToUncategorized(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11"}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	meal := func() string {
+		w := serve(r, http.MethodGet, "/entries/1", "alice", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET status = %d: %s", w.Code, w.Body)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &fields); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := fields["meal"].(string)
+		return got
+	}
+	if got := meal(); got != mealUncategorized {
+		t.Fatalf("meal = %q, want %q", got, mealUncategorized)
+	}
+
+	// Backfill still treats the entry as having no meal
+	w := serve(r, http.MethodPost, "/entries/backfill-meals?tz=UTC", "alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("backfill status = %d: %s", w.Code, w.Body)
+	}
+	var resp BackfillResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Updated != 1 {
+		t.Errorf("backfilled %d entries, want 1", resp.Updated)
+	}
+	if got := meal(); got == mealUncategorized {
+		t.Errorf("meal = %q after backfill, want an inferred meal", got)
+	}
+}
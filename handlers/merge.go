@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MergeEntriesRequest represents the request body for merging entries
+type MergeEntriesRequest struct {
+	IDs  []int `json:"ids" binding:"required,min=2,max=25,dive,gt=0" example:"3,5"`
+	Keep int   `json:"keep" binding:"required,gt=0" example:"3"`
+
+	// AllowCrossDate permits merging entries logged on different dates
+	AllowCrossDate bool `json:"allow_cross_date" example:"false"`
+}
+
+// MergeEntries godoc
+// @Summary Merge entries
+// @Description Append the foods of every listed entry into the kept entry and delete the others; the kept query joins every query with "and", kept first. Entries must share a date unless allow_cross_date is set
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param merge body MergeEntriesRequest true "Entries to merge"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse "Body larger than 64 KB"
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/merge [post]
+func (h *Handler) mergeEntries(c *gin.Context) {
+	var req MergeEntriesRequest
+	if status, err := bindStrictJSON(c, &req); err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := make(map[int]bool)
+	for _, id := range req.IDs {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Duplicate ID %d", id)})
+			return
+		}
+		seen[id] = true
+	}
+	if !seen[req.Keep] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "keep must be one of ids"})
+		return
+	}
+
+	// status is set when fn rejects the merge; any other error is a storage failure
+	status := http.StatusOK
+	userID := userIDFrom(c)
+	var kept Entry
+	err := h.updateEntries(func(current map[int]Entry) ([]Entry, []int, error) {
+		var exists bool
+		if kept, exists = current[req.Keep]; !exists || kept.UserID != userID {
+			status = http.StatusNotFound
+			return nil, nil, fmt.Errorf("entry %d not found", req.Keep)
+		}
+		for _, id := range req.IDs {
+			entry, exists := current[id]
+			if !exists || entry.UserID != userID {
+				status = http.StatusNotFound
+				return nil, nil, fmt.Errorf("entry %d not found", id)
+			}
+			if entry.Date != kept.Date && !req.AllowCrossDate {
+				status = http.StatusBadRequest
+				return nil, nil, errors.New("entries have different dates, set allow_cross_date to merge them")
+			}
+		}
+
+		foods := append([]Food(nil), kept.Nutrients.Foods...)
+		queries := []string{kept.Query}
+		var removed []int
+		for _, id := range req.IDs {
+			if id == req.Keep {
+				continue
+			}
+			foods = append(foods, current[id].Nutrients.Foods...)
+			queries = append(queries, current[id].Query)
+			removed = append(removed, id)
+		}
+		kept.Nutrients.Foods = foods
+		kept.Query = strings.Join(queries, " and ")
+		kept.NormalizedQuery = normalizeQuery(kept.Query)
+		kept.Source = sourceMerge
+		// Every food is already scaled by the servings of its own entry
+		kept.Servings = 0
+		kept.UpdatedAt = time.Now()
+		return []Entry{kept}, removed, nil
+	})
+	if err != nil {
+		if status == http.StatusOK {
+			log.Printf("Storage error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge entries"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	respond(c, http.StatusOK, kept)
+}
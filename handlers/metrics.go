@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a Nutritionix call; not_found is kept apart from error so
+// unrecognized foods do not look like an outage
+const (
+	nutritionixOutcomeSuccess  = "success"
+	nutritionixOutcomeCacheHit = "cache_hit"
+	nutritionixOutcomeNotFound = "not_found"
+	nutritionixOutcomeError    = "error"
+)
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	nutritionixCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "nutritionix_calls_total",
+		Help: "Nutritionix lookups by outcome: success, cache_hit, not_found or error.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequestDuration, nutritionixCalls)
+}
+
+// countNutritionixCall records the outcome of a Nutritionix call that reached upstream
+func countNutritionixCall(err error) {
+	switch {
+	case err == nil:
+		nutritionixCalls.WithLabelValues(nutritionixOutcomeSuccess).Inc()
+	case errors.Is(err, nutritionix.ErrNotFound), errors.Is(err, errBarcodeNotFound):
+		nutritionixCalls.WithLabelValues(nutritionixOutcomeNotFound).Inc()
+	default:
+		nutritionixCalls.WithLabelValues(nutritionixOutcomeError).Inc()
+	}
+}
+
+// requestMetrics observes the duration of every request; the route is the
+// registered pattern so IDs in the path do not blow up the label set
+func requestMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAPIKey guards a route with the X-API-Key header when API_KEY is configured
+func requireAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
+			return
+		}
+		if !validAPIKey(key) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid API key"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireAPIKeyForWrites applies requireAPIKey to POST, PUT, PATCH and DELETE
+// requests so reads and /health stay open
+func requireAPIKeyForWrites() gin.HandlerFunc {
+	auth := requireAPIKey()
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			auth(c)
+		default:
+			c.Next()
+		}
+	}
+}
+
+// validAPIKey reports whether the key matches the configured API_KEY
+func validAPIKey(key string) bool {
+	return apiKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1
+}
+
+// ipQuota counts entry creations per client IP for the current day
+type ipQuota struct {
+	mu     sync.Mutex
+	day    string
+	counts map[string]int
+}
+
+// reserve takes one unit of the IP's daily quota, resetting all counts when the day rolls over
+func (q *ipQuota) reserve(ip string, limit int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if today := time.Now().Format(dateLayout); q.day != today {
+		q.day = today
+		q.counts = make(map[string]int)
+	}
+	if q.counts[ip] >= limit {
+		return false
+	}
+	q.counts[ip]++
+	return true
+}
+
+// release returns a reserved unit when the creation did not succeed
+func (q *ipQuota) release(ip string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.counts[ip] > 0 {
+		q.counts[ip]--
+	}
+}
+
+var createQuota = &ipQuota{}
+
+// dailyEntryQuota limits entry creations per IP per day when MAX_ENTRIES_PER_IP_PER_DAY is set;
+// requests carrying a valid API key are exempt
+func dailyEntryQuota() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if maxEntriesPerIPPerDay <= 0 || validAPIKey(c.GetHeader("X-API-Key")) {
+			c.Next()
+			return
+		}
+
+		ip := c.ClientIP()
+		if !createQuota.reserve(ip, maxEntriesPerIPPerDay) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Daily entry quota exceeded"})
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			createQuota.release(ip)
+		}
+	}
+}
+
+// rateLimitIdle is how long an IP's bucket may sit unused before it is dropped;
+// an idle bucket would have refilled completely by then anyway
+const rateLimitIdle = 10 * time.Minute
+
+// tokenBucket is the rate limit state of one client IP
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// ipRateLimiter is a per-IP token bucket refilling at rps up to burst tokens
+type ipRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// allow takes a token from the IP's bucket; when none is left it returns how
+// long until the next token is available
+func (l *ipRateLimiter) allow(ip string, rps float64, burst int, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*tokenBucket)
+	}
+	if now.Sub(l.lastSweep) >= rateLimitIdle {
+		for key, b := range l.buckets {
+			if now.Sub(b.lastSeen) >= rateLimitIdle {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &tokenBucket{tokens: float64(burst), lastSeen: now}
+		l.buckets[ip] = b
+	}
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.lastSeen).Seconds()*rps)
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / rps * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+var createLimiter = &ipRateLimiter{}
+
+// rateLimit throttles Nutritionix-backed routes per client IP when RATE_LIMIT_RPS is set
+func rateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rateLimitRPS <= 0 {
+			c.Next()
+			return
+		}
+
+		ok, wait := createLimiter.allow(c.ClientIP(), rateLimitRPS, rateLimitBurst, time.Now())
+		if !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// createEntryFields are the optional JSON fields a create request may carry;
+// query and date are required and always allowed
+var createEntryFields = []string{"mood", "energy", "tags", "meal", "client_id", "latitude", "longitude", "servings"}
+
+// restrictCreateFields enforces CREATE_ALLOWED_FIELDS on a create request body,
+// rejecting disallowed fields with 400 in strict mode and dropping them otherwise.
+// The body may be one object or, for batch and transaction, an array of them
+func restrictCreateFields() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if allowedCreateFields == nil {
+			c.Next()
+			return
+		}
+
+		raw, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": errBodyTooLarge.Error()})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+
+		var items []map[string]json.RawMessage
+		isArray := bytes.HasPrefix(bytes.TrimSpace(raw), []byte("["))
+		if isArray {
+			err = json.Unmarshal(raw, &items)
+		} else {
+			items = make([]map[string]json.RawMessage, 1)
+			err = json.Unmarshal(raw, &items[0])
+		}
+		if err != nil {
+			// Leave malformed bodies to the handler's binding error
+			c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+			c.Next()
+			return
+		}
+
+		// Unknown fields are left for the handler to reject
+		found := make(map[string]bool)
+		for _, fields := range items {
+			for name := range fields {
+				if slices.Contains(createEntryFields, name) && !allowedCreateFields[name] {
+					found[name] = true
+					delete(fields, name)
+				}
+			}
+		}
+		if len(found) > 0 {
+			disallowed := make([]string, 0, len(found))
+			for name := range found {
+				disallowed = append(disallowed, name)
+			}
+			sort.Strings(disallowed)
+			if createFieldsStrict {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Fields not allowed: " + strings.Join(disallowed, ", ")})
+				return
+			}
+			if isArray {
+				raw, _ = json.Marshal(items)
+			} else {
+				raw, _ = json.Marshal(items[0])
+			}
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(raw))
+		c.Next()
+	}
+}
+
+// defaultRequestTimeout bounds the request context of routes without an entry
+// in routeTimeouts
+const defaultRequestTimeout = 30 * time.Second
+
+// routeTimeouts overrides defaultRequestTimeout per "METHOD /route/:param".
+// Routes calling Nutritionix once per food or ingredient get longer, since
+// every call may take up to NUTRITIONIX_TIMEOUT_SECONDS and is retried; plain
+// reads of stored entries get a short one
+var routeTimeouts = map[string]time.Duration{
+	"POST /entries":              60 * time.Second,
+	"PUT /entries/:id":           60 * time.Second,
+	"POST /entries/:id/refresh":  60 * time.Second,
+	"POST /entries/barcode/:upc": 60 * time.Second,
+	"POST /entries/batch":        2 * time.Minute,
+	"POST /entries/transaction":  2 * time.Minute,
+	"POST /recipes":              2 * time.Minute,
+	"GET /entries":               5 * time.Second,
+	"GET /entries/:id":           5 * time.Second,
+}
+
+// requestTimeout puts a deadline on the request context, taken from
+// routeTimeouts or defaultRequestTimeout; Nutritionix calls made with that
+// context are canceled once it passes
+func requestTimeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			timeout = defaultRequestTimeout
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// allowedOrigins are the CORS origins from ALLOWED_ORIGINS; "*" allows any origin
+var allowedOrigins = []string{"*"}
+
+// CORS headers advertised to browsers; the custom headers cover the API key,
+// health token, request ID, user ID, idempotency key and conditional GET
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Content-Type, Authorization, X-API-Key, X-Health-Token, X-Request-ID, X-User-ID, Idempotency-Key, If-Modified-Since"
+	corsExposeHeaders = "Last-Modified, Retry-After, X-Request-ID, Idempotent-Replayed"
+	corsMaxAge        = "600"
+)
+
+// corsOrigin returns the Access-Control-Allow-Origin value for a request origin,
+// or "" when the origin is not allowed
+func corsOrigin(origin string) string {
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// cors sets the CORS headers for allowed origins and answers OPTIONS preflight
+// requests with 204; it must be registered on the engine so preflights for
+// routes without an OPTIONS handler reach it
+func cors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		if allow := corsOrigin(origin); allow != "" {
+			h := c.Writer.Header()
+			h.Set("Access-Control-Allow-Origin", allow)
+			if allow != "*" {
+				h.Add("Vary", "Origin")
+			}
+			h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			if c.Request.Method == http.MethodOptions {
+				h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+				h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				h.Set("Access-Control-Max-Age", corsMaxAge)
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+)
+
+// hangingNutrients never answers a query until its context is done
+type hangingNutrients struct {
+	stubNutritionix
+}
+
+func (n *hangingNutrients) Nutrients(ctx context.Context, query string) (nutritionix.Response, error) {
+	<-ctx.Done()
+	return nutritionix.Response{}, ctx.Err()
+}
+
+func TestRequestTimeoutPerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestTimeout())
+	remaining := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, time.Until(deadline).Round(time.Second).String())
+	}
+	r.GET("/entries/:id", remaining)
+	r.POST("/entries/batch", remaining)
+	r.GET("/summary", remaining)
+
+	tests := []struct {
+		method, path string
+		want         time.Duration
+	}{
+		{http.MethodGet, "/entries/7", routeTimeouts["GET /entries/:id"]},
+		{http.MethodPost, "/entries/batch", routeTimeouts["POST /entries/batch"]},
+		{http.MethodGet, "/summary", defaultRequestTimeout},
+	}
+	for _, tt := range tests {
+		w := serve(r, tt.method, tt.path, "alice", "")
+		if w.Code != http.StatusOK || w.Body.String() != tt.want.String() {
+			t.Errorf("%s %s: deadline in %s (status %d), want %s", tt.method, tt.path, w.Body, w.Code, tt.want)
+		}
+	}
+}
+
+func TestRequestTimeoutCancelsNutritionix(t *testing.T) {
+	defer func(d time.Duration) { routeTimeouts["POST /entries"] = d }(routeTimeouts["POST /entries"])
+	routeTimeouts["POST /entries"] = 50 * time.Millisecond
+
+	gin.SetMode(gin.TestMode)
+	s := newFakeStore()
+	r := gin.New()
+	New(s, &hangingNutrients{}).Register(r)
+
+	start := time.Now()
+	w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11"}`)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d: %s", w.Code, http.StatusBadGateway, w.Body)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("create returned after %v, want it to stop at the route deadline", elapsed)
+	}
+	if n := s.Count(); n != 0 {
+		t.Errorf("stored %d entries, want 0", n)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+)
+
+// API Client
+
+// Nutritionix retry policy for network errors and 429/5xx responses
+const (
+	nutritionixMaxRetries  = 3
+	nutritionixBaseBackoff = 200 * time.Millisecond
+)
+
+// Connection pool of the shared Nutritionix client; every call goes to the
+// same host, so keep enough idle connections around to skip TLS handshakes
+const (
+	nutritionixMaxIdleConns = 32
+	nutritionixIdleTimeout  = 90 * time.Second
+)
+
+// Nutritionix is the part of the Nutritionix client the handlers use
+type Nutritionix interface {
+	Nutrients(ctx context.Context, query string) (nutritionix.Response, error)
+	Search(ctx context.Context, query string) (nutritionix.SearchResponse, error)
+	Item(ctx context.Context, upc string) ([]nutritionix.ItemFood, error)
+}
+
+// NewNutritionixHTTPClient returns an HTTP client with a pooled transport; one
+// client should be shared by all Nutritionix calls so connections are reused
+func NewNutritionixHTTPClient(timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		MaxIdleConns:          nutritionixMaxIdleConns,
+		MaxIdleConnsPerHost:   nutritionixMaxIdleConns,
+		IdleConnTimeout:       nutritionixIdleTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// fetchNutrients returns the Nutritionix response for query, served from the
+// cache when an identical normalized query was fetched within CACHE_TTL_SECONDS;
+// concurrent misses share one upstream call. Cancelling ctx aborts the wait, and
+// the in-flight call and any pending retries when this caller started them
+func (h *Handler) fetchNutrients(ctx context.Context, query string) (NutritionixResponse, error) {
+	key := normalizeQuery(query)
+	for {
+		if nutrients, ok := h.cache.get(key); ok {
+			nutritionixCalls.WithLabelValues(nutritionixOutcomeCacheHit).Inc()
+			return nutrients, nil
+		}
+
+		ch := h.flight.DoChan(key, func() (interface{}, error) {
+			nutrients, err := h.requestNutrients(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			h.cache.put(key, nutrients)
+			return nutrients, nil
+		})
+		select {
+		case <-ctx.Done():
+			return NutritionixResponse{}, ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				// The caller that started the shared call went away; try again on our own
+				if res.Shared && ctx.Err() == nil && (errors.Is(res.Err, context.Canceled) || errors.Is(res.Err, context.DeadlineExceeded)) {
+					continue
+				}
+				return NutritionixResponse{}, res.Err
+			}
+			// Every waiter gets its own copy, callers may modify the foods
+			return cloneNutrients(res.Val.(NutritionixResponse)), nil
+		}
+	}
+}
+
+// requestNutrients queries Nutritionix directly, bypassing the cache; transient
+// failures are retried with exponential backoff and jitter
+func (h *Handler) requestNutrients(ctx context.Context, query string) (NutritionixResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= nutritionixMaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := nutritionixBaseBackoff << (attempt - 1)
+			backoff += time.Duration(mathrand.Int63n(int64(backoff / 2)))
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return NutritionixResponse{}, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if attempt > 0 {
+			loggerFrom(ctx).Warn("Retrying Nutritionix request", "attempt", attempt, "error", lastErr)
+		}
+		nutrients, err := h.nutritionix.Nutrients(ctx, query)
+		countNutritionixCall(err)
+		if err == nil {
+			detectAllergens(nutrients.Foods)
+			return nutrients, nil
+		}
+		if !nutritionix.Temporary(err) || ctx.Err() != nil {
+			return NutritionixResponse{}, err
+		}
+		lastErr = err
+	}
+	return NutritionixResponse{}, lastErr
+}
+
+// nutritionixFailure maps a fetchNutrients error to the status and message
+// returned to the client; credential problems are ours, not the client's
+func nutritionixFailure(err error) (int, string) {
+	switch {
+	case errors.Is(err, nutritionix.ErrNotFound):
+		return http.StatusUnprocessableEntity, "Could not recognize that food"
+	case errors.Is(err, nutritionix.ErrUnauthorized):
+		return http.StatusInternalServerError, "Failed to fetch nutrition data"
+	default:
+		return http.StatusBadGateway, "Failed to fetch nutrition data"
+	}
+}
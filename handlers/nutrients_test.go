@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+)
+
+func TestFetchNutrientsSharesConcurrentMisses(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		// Stay in flight long enough for every caller to miss the cache
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"foods":[{"food_name":"apple","nf_calories":95}]}`))
+	}))
+	defer srv.Close()
+
+	h := New(newFakeStore(), &nutritionix.Client{HTTP: srv.Client(), BaseURL: srv.URL})
+
+	const callers = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nutrients, err := h.fetchNutrients(context.Background(), "1 Apple")
+			if err == nil && len(nutrients.Foods) != 1 {
+				t.Errorf("got %d foods, want 1", len(nutrients.Foods))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("fetchNutrients: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("upstream calls = %d, want 1", n)
+	}
+}
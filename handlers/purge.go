@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Automatic purge settings; disabled by default to avoid surprising data loss
+var autoPurgeInterval = time.Hour
+
+// isIncomplete reports whether Nutritionix returned no usable data for an entry
+func isIncomplete(entry Entry) bool {
+	return len(entry.Nutrients.Foods) == 0 || entryTotals(entry).Calories == 0
+}
+
+// purgeIncompleteEntries deletes every incomplete entry and returns how many were removed
+func (h *Handler) purgeIncompleteEntries() (int, error) {
+	var purged int
+	err := h.updateEntries(func(current map[int]Entry) ([]Entry, []int, error) {
+		var remove []int
+		for id, entry := range current {
+			if isIncomplete(entry) {
+				remove = append(remove, id)
+			}
+		}
+		purged = len(remove)
+		return nil, remove, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// RunAutoPurge purges incomplete entries every autoPurgeInterval until ctx is done
+func (h *Handler) RunAutoPurge(ctx context.Context) {
+	ticker := time.NewTicker(autoPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := h.purgeIncompleteEntries()
+			if err != nil {
+				log.Printf("Auto purge failed: %v", err)
+				continue
+			}
+			log.Printf("Auto purge removed %d incomplete entries", purged)
+		}
+	}
+}
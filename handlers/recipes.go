@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"fierda/go_nutrition/store"
+	"github.com/gin-gonic/gin"
+)
+
+// Recipe represents a saved combination of ingredients
+type Recipe struct {
+	Name        string              `json:"name" example:"overnight oats"`
+	Ingredients []string            `json:"ingredients" example:"1 cup oats,1 cup milk"`
+	Servings    float64             `json:"servings" example:"2"`
+	Nutrients   NutritionixResponse `json:"nutrients"`
+	Totals      Totals              `json:"totals"`
+	CreatedAt   time.Time           `json:"created_at" example:"2025-08-11T10:00:00Z"`
+}
+
+// CreateRecipeRequest represents the request body for creating a recipe
+type CreateRecipeRequest struct {
+	Name        string   `json:"name" binding:"required,max=64" example:"overnight oats"`
+	Ingredients []string `json:"ingredients" binding:"required,min=1,max=25,dive,required" example:"1 cup oats,1 cup milk"`
+	Servings    float64  `json:"servings" binding:"required,gt=0" example:"2"`
+}
+
+// RecipeEntryRequest represents the request body for logging a recipe serving
+type RecipeEntryRequest struct {
+	Date string `json:"date" binding:"required" example:"2025-08-11" format:"date"`
+	Meal string `json:"meal" binding:"omitempty,oneof=breakfast lunch dinner snack" example:"breakfast" enums:"breakfast,lunch,dinner,snack"`
+}
+
+// toRecipe adds the totals to a stored recipe
+func toRecipe(stored store.Recipe) Recipe {
+	recipe := Recipe{
+		Name:        stored.Name,
+		Ingredients: stored.Ingredients,
+		Servings:    stored.Servings,
+		Nutrients:   stored.Nutrients,
+		CreatedAt:   stored.CreatedAt,
+	}
+	for _, food := range recipe.Nutrients.Foods {
+		recipe.Totals.AddFood(food)
+	}
+	return recipe
+}
+
+// userRecipes returns the recipes of userID ordered by name
+func (h *Handler) userRecipes(userID string) ([]Recipe, error) {
+	stored, err := h.store.Recipes(userID)
+	if err != nil {
+		return nil, err
+	}
+	recipes := make([]Recipe, len(stored))
+	for i, r := range stored {
+		recipes[i] = toRecipe(r)
+	}
+	return recipes, nil
+}
+
+// recipeKey is the case-insensitive lookup key of a recipe name
+func recipeKey(name string) string {
+	return normalizeQuery(name)
+}
+
+// scaleFood multiplies every quantity and nutrient of a food by factor
+func scaleFood(food Food, factor float64) Food {
+	food.ServingQty *= factor
+	food.ServingWeight *= factor
+	food.NFCalories *= factor
+	food.NFProtein *= factor
+	food.NFTotalFat *= factor
+	food.NFTotalCarbs *= factor
+	food.NFSodium *= factor
+	food.NFSugars *= factor
+	food.NFDietaryFiber *= factor
+	return food
+}
+
+// CreateRecipe godoc
+// @Summary Create a recipe
+// @Description Fetch the nutrients of every ingredient once and store the recipe aggregate for the user; names are unique per user, ignoring case
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Param recipe body CreateRecipeRequest true "Recipe data"
+// @Param X-User-ID header string true "User whose recipes are read or written"
+// @Success 201 {object} Recipe
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /recipes [post]
+func (h *Handler) createRecipe(c *gin.Context) {
+	var req CreateRecipeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, key := userIDFrom(c), recipeKey(req.Name)
+	_, exists, err := h.store.GetRecipe(userID, key)
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recipe"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recipe already exists"})
+		return
+	}
+
+	recipe := store.Recipe{
+		Name:        strings.TrimSpace(req.Name),
+		Ingredients: req.Ingredients,
+		Servings:    req.Servings,
+		CreatedAt:   time.Now(),
+	}
+	for i, ingredient := range req.Ingredients {
+		nutrients, err := h.fetchNutrients(c.Request.Context(), ingredient)
+		if err != nil {
+			logNutritionixError(c.Request.Context(), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch nutrition data for ingredient %d", i)})
+			return
+		}
+		recipe.Nutrients.Foods = append(recipe.Nutrients.Foods, nutrients.Foods...)
+	}
+
+	// Another request may have created the recipe while the ingredients were fetched
+	err = h.store.CreateRecipe(userID, key, recipe)
+	if errors.Is(err, store.ErrRecipeExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Recipe already exists"})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recipe"})
+		return
+	}
+
+	respond(c, http.StatusCreated, toRecipe(recipe))
+}
+
+// GetRecipes godoc
+// @Summary Get all recipes
+// @Description Get the saved recipes of the user ordered by name
+// @Tags recipes
+// @Produce json
+// @Param X-User-ID header string true "User whose recipes are read or written"
+// @Success 200 {array} Recipe
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /recipes [get]
+func (h *Handler) getRecipes(c *gin.Context) {
+	result, err := h.userRecipes(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recipes"})
+		return
+	}
+	respond(c, http.StatusOK, result)
+}
+
+// CreateEntryFromRecipe godoc
+// @Summary Log a recipe serving
+// @Description Log one serving of one of the user's saved recipes (the aggregate divided by its servings) without querying Nutritionix again
+// @Tags entries
+// @Accept json
+// @Produce json
+// @Param name path string true "Recipe name"
+// @Param entry body RecipeEntryRequest true "Entry data"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 201 {object} Entry
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/from-recipe/{name} [post]
+func (h *Handler) createEntryFromRecipe(c *gin.Context) {
+	var req RecipeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe, exists, err := h.store.GetRecipe(userIDFrom(c), recipeKey(c.Param("name")))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load recipe"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	serving := NutritionixResponse{Foods: make([]Food, len(recipe.Nutrients.Foods))}
+	for i, food := range recipe.Nutrients.Foods {
+		serving.Foods[i] = scaleFood(food, 1/recipe.Servings)
+	}
+
+	entry := newEntry(CreateEntryRequest{Query: recipe.Name, Date: req.Date, Meal: req.Meal}, serving)
+	entry.UserID = userIDFrom(c)
+	entry.Source = sourceRecipe
+	created, err := h.insertEntries([]Entry{entry})
+	if errors.Is(err, errDuplicateClientID) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save entry"})
+		return
+	}
+
+	respond(c, http.StatusCreated, created[0])
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRecipesAreScopedToUser(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	recipe := `{"name":"Oats","ingredients":["1 cup oats","1 cup milk"],"servings":2}`
+	if w := serve(r, http.MethodPost, "/recipes", "alice", recipe); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodPost, "/recipes", "alice", `{"name":"oats","ingredients":["1 cup oats"],"servings":1}`); w.Code != http.StatusConflict {
+		t.Errorf("duplicate name status = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	list := func(user string) []Recipe {
+		w := serve(r, http.MethodGet, "/recipes", user, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("list status = %d: %s", w.Code, w.Body)
+		}
+		var recipes []Recipe
+		if err := json.Unmarshal(w.Body.Bytes(), &recipes); err != nil {
+			t.Fatal(err)
+		}
+		return recipes
+	}
+	if got := list("alice"); len(got) != 1 || got[0].Totals.Calories != 600 {
+		t.Errorf("alice recipes = %+v, want oats with 600 kcal", got)
+	}
+	if got := list("bob"); len(got) != 0 {
+		t.Errorf("bob sees %d recipes of alice", len(got))
+	}
+	if w := serve(r, http.MethodPost, "/entries/from-recipe/oats", "bob", `{"date":"2025-08-11"}`); w.Code != http.StatusNotFound {
+		t.Errorf("bob logging alice's recipe status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(r, http.MethodPost, "/recipes", "bob", recipe); w.Code != http.StatusCreated {
+		t.Errorf("bob creating the same name status = %d, want %d", w.Code, http.StatusCreated)
+	}
+	if w := serve(r, http.MethodGet, "/recipes", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("list without X-User-ID status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
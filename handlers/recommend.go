@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minProteinPer100Kcal is the protein density a food needs to be suggested;
+// 5 g per 100 kcal means protein supplies at least 20% of its energy
+const minProteinPer100Kcal = 5.0
+
+// ProteinSuggestion represents a previously logged food that helps close a protein gap
+type ProteinSuggestion struct {
+	FoodName          string  `json:"food_name" example:"greek yogurt"`
+	ServingSize       string  `json:"serving_size" example:"1.0 container"`
+	Calories          float64 `json:"calories" example:"146"`
+	Protein           float64 `json:"protein_g" example:"20"`
+	ProteinPer100Kcal float64 `json:"protein_per_100kcal" example:"13.7"`
+}
+
+// ProteinRecommendationResponse represents the remaining protein of a day and foods to close it
+type ProteinRecommendationResponse struct {
+	Date              string              `json:"date" example:"2025-08-11"`
+	ProteinGoal       float64             `json:"protein_goal_g" example:"120"`
+	ProteinLogged     float64             `json:"protein_logged_g" example:"85.5"`
+	ProteinRemaining  float64             `json:"protein_remaining_g" example:"34.5"`
+	CaloriesRemaining float64             `json:"calories_remaining" example:"620"`
+	Suggestions       []ProteinSuggestion `json:"suggestions"`
+}
+
+// GetProteinRecommendation godoc
+// @Summary Suggest foods to close the protein gap
+// @Description Compute the protein still needed to reach the goal on a date and suggest previously logged high-protein foods (at least 5 g per 100 kcal) that fit the remaining calorie budget, ranked by protein per calorie; suggestions are empty once the protein goal is met
+// @Tags recommend
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param limit query int false "Maximum number of suggestions (default 5, max 20)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} ProteinRecommendationResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /recommend/protein [get]
+func (h *Handler) getProteinRecommendation(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 20"})
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok || g.Protein <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No protein goal set"})
+		return
+	}
+
+	var eaten Totals
+	latest := make(map[string]Food) // lowercased food name -> most recently logged serving
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if entry.Date == date {
+			eaten.AddEntry(entry)
+		}
+		for _, food := range entry.Nutrients.Foods {
+			latest[strings.ToLower(food.FoodName)] = food
+		}
+	}
+
+	resp := ProteinRecommendationResponse{
+		Date:              date,
+		ProteinGoal:       g.Protein,
+		ProteinLogged:     roundAmount(eaten.Protein),
+		ProteinRemaining:  roundAmount(math.Max(g.Protein-eaten.Protein, 0)),
+		CaloriesRemaining: roundAmount(math.Max(g.Calories-eaten.Calories, 0)),
+		Suggestions:       []ProteinSuggestion{},
+	}
+	if resp.ProteinRemaining > 0 {
+		for _, food := range latest {
+			if food.NFCalories <= 0 || food.NFCalories > resp.CaloriesRemaining {
+				continue
+			}
+			density := food.NFProtein / food.NFCalories * 100
+			if density < minProteinPer100Kcal {
+				continue
+			}
+			resp.Suggestions = append(resp.Suggestions, ProteinSuggestion{
+				FoodName:          food.FoodName,
+				ServingSize:       fmt.Sprintf("%.1f %s", food.ServingQty, food.ServingUnit),
+				Calories:          roundAmount(food.NFCalories),
+				Protein:           roundAmount(food.NFProtein),
+				ProteinPer100Kcal: roundAmount(density),
+			})
+		}
+		sort.Slice(resp.Suggestions, func(i, j int) bool {
+			a, b := resp.Suggestions[i], resp.Suggestions[j]
+			if a.ProteinPer100Kcal != b.ProteinPer100Kcal {
+				return a.ProteinPer100Kcal > b.ProteinPer100Kcal
+			}
+			return a.FoodName < b.FoodName
+		})
+		if len(resp.Suggestions) > limit {
+			resp.Suggestions = resp.Suggestions[:limit]
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
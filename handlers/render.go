@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// outputOptions are the response tweaks a client can request via the query
+type outputOptions struct {
+	StringNumbers bool // numbers=string
+	StripPhotos   bool // photos=false
+	Kilojoules    bool // energy=kj
+	TitleCase     bool // FOOD_NAME_CASE=title (server config)
+
+	// Scales multiplies JSON fields by a factor to switch nutrient units (e.g. sodium_unit=g)
+	Scales map[string]float64
+}
+
+// unitOption is a query param selecting the display unit of a nutrient
+type unitOption struct {
+	Param   string
+	Default string             // unit of the stored values, as returned by Nutritionix
+	Factors map[string]float64 // factor from Default to each accepted unit
+	Fields  []string           // JSON fields carrying the nutrient
+}
+
+// unitOptions are the per-nutrient unit params; energy is handled by energy=kj
+var unitOptions = []unitOption{
+	{Param: "sodium_unit", Default: "mg", Factors: map[string]float64{"mg": 1, "g": 0.001}, Fields: []string{"nf_sodium"}},
+	{Param: "sugars_unit", Default: "g", Factors: map[string]float64{"g": 1, "mg": 1000}, Fields: []string{"nf_sugars"}},
+	{Param: "fiber_unit", Default: "g", Factors: map[string]float64{"g": 1, "mg": 1000}, Fields: []string{"nf_dietary_fiber"}},
+}
+
+// unitFactor returns the factor converting the option's default unit to unit
+func (u unitOption) unitFactor(unit string) (float64, error) {
+	if unit == "" {
+		return 1, nil
+	}
+	factor, ok := u.Factors[unit]
+	if !ok {
+		accepted := make([]string, 0, len(u.Factors))
+		for name := range u.Factors {
+			accepted = append(accepted, name)
+		}
+		sort.Strings(accepted)
+		return 0, fmt.Errorf("invalid %s %q, expected one of %s", u.Param, unit, strings.Join(accepted, ", "))
+	}
+	return factor, nil
+}
+
+func parseOutputOptions(c *gin.Context) (outputOptions, error) {
+	opts := outputOptions{
+		StringNumbers: c.Query("numbers") == "string",
+		StripPhotos:   c.Query("photos") == "false",
+		Kilojoules:    c.Query("energy") == "kj",
+		TitleCase:     foodNameCase == "title",
+	}
+	if energy := c.Query("energy"); energy != "" && energy != "kcal" && energy != "kj" {
+		return opts, fmt.Errorf("invalid energy %q, expected kcal or kj", energy)
+	}
+
+	for _, u := range unitOptions {
+		factor, err := u.unitFactor(c.Query(u.Param))
+		if err != nil {
+			return opts, err
+		}
+		if factor == 1 {
+			continue
+		}
+		if opts.Scales == nil {
+			opts.Scales = make(map[string]float64)
+		}
+		for _, field := range u.Fields {
+			opts.Scales[field] = factor
+		}
+	}
+	return opts, nil
+}
+
+// active reports whether any option requires reshaping the response
+func (o outputOptions) active() bool {
+	return o.StringNumbers || o.StripPhotos || o.Kilojoules || o.TitleCase || len(o.Scales) > 0
+}
+
+// kjPerKcal converts kilocalories to kilojoules
+const kjPerKcal = 4.184
+
+// kcalToKJ converts an energy value from kilocalories to kilojoules
+func kcalToKJ(kcal float64) float64 {
+	return kcal * kjPerKcal
+}
+
+// isCalorieField reports whether a JSON field carries an energy value in kcal
+func isCalorieField(key string) bool {
+	return key == "calories" || strings.HasSuffix(key, "_calories")
+}
+
+// titleCase upper-cases the first letter of every word
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// respond writes an entry or summary response, honoring the output options in
+// the query; clients sending Accept: application/msgpack get MessagePack instead of JSON
+func respond(c *gin.Context, code int, obj interface{}) {
+	opts, err := parseOutputOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if wantsMsgPack(c) {
+		if opts.active() {
+			tree, err := projection{obj, opts}.tree()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+				return
+			}
+			obj = tree
+		}
+		c.Render(code, render.MsgPack{Data: obj})
+		return
+	}
+
+	if opts.active() {
+		obj = projection{obj, opts}
+	}
+	c.JSON(code, obj)
+}
+
+// wantsMsgPack reports whether the client accepts MessagePack
+func wantsMsgPack(c *gin.Context) bool {
+	accept := c.GetHeader("Accept")
+	return strings.Contains(accept, "application/msgpack") || strings.Contains(accept, "application/x-msgpack")
+}
+
+// numericStringFields are the nutrient fields quoted in numbers=string mode
+var numericStringFields = map[string]bool{
+	"serving_qty":           true,
+	"serving_weight_grams":  true,
+	"nf_calories":           true,
+	"nf_protein":            true,
+	"nf_total_fat":          true,
+	"nf_total_carbohydrate": true,
+	"nf_sodium":             true,
+	"nf_sugars":             true,
+	"nf_dietary_fiber":      true,
+	"calories":              true,
+	"protein_g":             true,
+	"carbs_g":               true,
+	"fat_g":                 true,
+	"fiber_g":               true,
+	"glycemic_load":         true,
+	"co2_estimate_kg":       true,
+}
+
+// photoFields are the image fields dropped in photos=false mode
+var photoFields = map[string]bool{
+	"photo":     true,
+	"image_url": true,
+}
+
+// projection marshals the wrapped value and then reshapes the JSON tree
+// according to the output options, so every response type is handled alike
+type projection struct {
+	v    interface{}
+	opts outputOptions
+}
+
+func (p projection) MarshalJSON() ([]byte, error) {
+	tree, err := p.tree()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+// tree returns the reshaped value as generic maps and slices
+func (p projection) tree() (interface{}, error) {
+	raw, err := json.Marshal(p.v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree interface{}
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	return numbersToFloats(p.reshape(tree, "")), nil
+}
+
+// numbersToFloats replaces json.Number leaves with float64 so non-JSON encoders
+// such as MessagePack see plain numbers
+func numbersToFloats(node interface{}) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = numbersToFloats(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = numbersToFloats(child)
+		}
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return node
+}
+
+// reshape walks a decoded JSON tree applying the options to each field;
+// in kJ mode calorie fields are converted and renamed (calories -> kj)
+func (p projection) reshape(node interface{}, key string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			if p.opts.StripPhotos && photoFields[k] {
+				continue
+			}
+			name := k
+			if p.opts.Kilojoules && isCalorieField(k) {
+				name = strings.Replace(k, "calories", "kj", 1)
+			}
+			out[name] = p.reshape(child, k)
+		}
+		return out
+	case []interface{}:
+		for i, child := range v {
+			v[i] = p.reshape(child, key)
+		}
+	case string:
+		if p.opts.TitleCase && key == "food_name" {
+			return titleCase(v)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return node
+		}
+		if factor, ok := p.opts.Scales[key]; ok {
+			f = math.Round(f*factor*1e6) / 1e6 // drop float noise such as 0.30000000000000004
+			node = json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+		energy := isCalorieField(key)
+		if p.opts.Kilojoules && energy {
+			f = kcalToKJ(f)
+			node = json.Number(strconv.FormatFloat(f, 'f', 2, 64))
+		}
+		if p.opts.StringNumbers && (numericStringFields[key] || energy) {
+			return strconv.FormatFloat(f, 'f', 2, 64)
+		}
+	}
+	return node
+}
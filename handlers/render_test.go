@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ugorji/go/codec"
+)
+
+func TestRespondMsgPackRoundTrip(t *testing.T) {
+	r, _, _ := newTestRouter(t)
+	if w := serve(r, http.MethodPost, "/entries", "alice", `{"query":"1 apple","date":"2025-08-11","tags":["home"]}`); w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d: %s", w.Code, w.Body)
+	}
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-User-ID", "alice")
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d: %s", path, w.Code, w.Body)
+		}
+		return w
+	}
+
+	var fromJSON Entry
+	if err := json.Unmarshal(get("/entries/1", "application/json").Body.Bytes(), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	w := get("/entries/1", "application/msgpack")
+	if ct := w.Header().Get("Content-Type"); ct != "application/msgpack; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want application/msgpack", ct)
+	}
+	var fromMsgPack Entry
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&fromMsgPack); err != nil {
+		t.Fatalf("decode msgpack: %v", err)
+	}
+	// Timestamps lose the monotonic clock and location in either encoding
+	if !fromMsgPack.CreatedAt.Equal(fromJSON.CreatedAt) || !fromMsgPack.UpdatedAt.Equal(fromJSON.UpdatedAt) {
+		t.Errorf("timestamps = %v/%v, want %v/%v", fromMsgPack.CreatedAt, fromMsgPack.UpdatedAt, fromJSON.CreatedAt, fromJSON.UpdatedAt)
+	}
+	fromMsgPack.CreatedAt, fromMsgPack.UpdatedAt = fromJSON.CreatedAt, fromJSON.UpdatedAt
+	if !reflect.DeepEqual(fromMsgPack, fromJSON) {
+		t.Errorf("msgpack entry = %+v\nwant %+v", fromMsgPack, fromJSON)
+	}
+
+	// Output options reshape the MessagePack response like the JSON one
+	var reshaped map[string]interface{}
+	w = get("/entries/1?energy=kj&photos=false", "application/msgpack")
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&reshaped); err != nil {
+		t.Fatalf("decode msgpack: %v", err)
+	}
+	foods := reshaped["nutrients"].(map[interface{}]interface{})["foods"].([]interface{})
+	food := foods[0].(map[interface{}]interface{})
+	if _, ok := food["photo"]; ok {
+		t.Error("photo kept with photos=false")
+	}
+	// 200 kcal, rounded to two decimals
+	if kj, ok := food["nf_kj"]; !ok || kj != 836.8 {
+		t.Errorf("nf_kj = %v, want 836.8", kj)
+	}
+}
+
+func TestKcalToKJ(t *testing.T) {
+	tests := []struct {
+		kcal, want float64
+	}{
+		{0, 0},
+		{1, 4.184},
+		{100, 418.4},
+		{250, 1046},
+		{-10, -41.84},
+	}
+	for _, tt := range tests {
+		if got := kcalToKJ(tt.kcal); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("kcalToKJ(%v) = %v, want %v", tt.kcal, got, tt.want)
+		}
+	}
+}
+
+func TestUnitFactor(t *testing.T) {
+	sodium, sugars := unitOptions[0], unitOptions[1]
+	tests := []struct {
+		option  unitOption
+		unit    string
+		want    float64
+		wantErr string
+	}{
+		{sodium, "", 1, ""},
+		{sodium, "mg", 1, ""},
+		{sodium, "g", 0.001, ""},
+		{sugars, "g", 1, ""},
+		{sugars, "mg", 1000, ""},
+		{sodium, "kg", 0, `invalid sodium_unit "kg", expected one of g, mg`},
+		{sugars, "MG", 0, `invalid sugars_unit "MG", expected one of g, mg`},
+	}
+	for _, tt := range tests {
+		got, err := tt.option.unitFactor(tt.unit)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s=%q: err = %v, want %q", tt.option.Param, tt.unit, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s=%q: got %v, %v, want %v", tt.option.Param, tt.unit, got, err, tt.want)
+		}
+	}
+}
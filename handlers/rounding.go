@@ -0,0 +1,56 @@
+package handlers
+
+import "math"
+
+// Rounding modes accepted by ROUNDING_MODE
+const (
+	RoundHalfUp   = "half_up"
+	RoundHalfEven = "half_even"
+	RoundTruncate = "truncate"
+)
+
+// roundingDecimals is the precision of rounded nutrient amounts
+const roundingDecimals = 2
+
+// roundingMode is the configured ROUNDING_MODE
+var roundingMode = RoundHalfUp
+
+// validRoundingMode reports whether mode is a supported rounding mode
+func validRoundingMode(mode string) bool {
+	return mode == RoundHalfUp || mode == RoundHalfEven || mode == RoundTruncate
+}
+
+// roundAmount rounds a nutrient amount to roundingDecimals using the configured
+// mode; half_up rounds halves away from zero, like most nutrition labels
+func roundAmount(f float64) float64 {
+	scale := math.Pow10(roundingDecimals)
+	// Snap float noise such as 2.675*100 = 267.49999999999997 to the half step
+	scaled := f * scale
+	if halves := math.Round(scaled * 2); math.Abs(scaled*2-halves) < 1e-6 {
+		scaled = halves / 2
+	}
+
+	switch roundingMode {
+	case RoundHalfEven:
+		scaled = math.RoundToEven(scaled)
+	case RoundTruncate:
+		scaled = math.Trunc(scaled)
+	default:
+		scaled = math.Round(scaled)
+	}
+	return scaled / scale
+}
+
+// Rounded returns the totals with every amount passed through roundAmount
+func (t Totals) Rounded() Totals {
+	return Totals{
+		Calories: roundAmount(t.Calories),
+		Protein:  roundAmount(t.Protein),
+		Carbs:    roundAmount(t.Carbs),
+		Fat:      roundAmount(t.Fat),
+		Fiber:    roundAmount(t.Fiber),
+
+		GlycemicLoad: roundAmount(t.GlycemicLoad),
+		CO2Estimate:  roundAmount(t.CO2Estimate),
+	}
+}
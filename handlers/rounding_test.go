@@ -0,0 +1,57 @@
+package handlers
+
+import "testing"
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		mode string
+		in   float64
+		want float64
+	}{
+		{RoundHalfUp, 1.234, 1.23},
+		{RoundHalfUp, 1.236, 1.24},
+		{RoundHalfUp, 1.235, 1.24},
+		{RoundHalfUp, 1.225, 1.23},
+		{RoundHalfUp, 2.675, 2.68}, // 2.675*100 is 267.49999999999997 in float64
+		{RoundHalfUp, 0.005, 0.01},
+		{RoundHalfUp, -1.235, -1.24},
+		{RoundHalfUp, -1.234, -1.23},
+		{RoundHalfUp, 0, 0},
+
+		{RoundHalfEven, 1.234, 1.23},
+		{RoundHalfEven, 1.236, 1.24},
+		{RoundHalfEven, 1.235, 1.24},
+		{RoundHalfEven, 1.225, 1.22},
+		{RoundHalfEven, 2.675, 2.68},
+		{RoundHalfEven, 0.005, 0},
+		{RoundHalfEven, -1.225, -1.22},
+		{RoundHalfEven, -1.235, -1.24},
+
+		{RoundTruncate, 1.239, 1.23},
+		{RoundTruncate, 1.235, 1.23},
+		{RoundTruncate, 2.675, 2.67},
+		{RoundTruncate, 0.009, 0},
+		{RoundTruncate, -1.239, -1.23},
+		{RoundTruncate, -0.005, 0},
+	}
+	defer func(mode string) { roundingMode = mode }(roundingMode)
+	for _, tt := range tests {
+		roundingMode = tt.mode
+		if got := roundAmount(tt.in); got != tt.want {
+			t.Errorf("%s: roundAmount(%v) = %v, want %v", tt.mode, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestValidRoundingMode(t *testing.T) {
+	for _, mode := range []string{RoundHalfUp, RoundHalfEven, RoundTruncate} {
+		if !validRoundingMode(mode) {
+			t.Errorf("validRoundingMode(%q) = false, want true", mode)
+		}
+	}
+	for _, mode := range []string{"", "half_down", "HALF_UP"} {
+		if validRoundingMode(mode) {
+			t.Errorf("validRoundingMode(%q) = true, want false", mode)
+		}
+	}
+}
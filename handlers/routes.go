@@ -0,0 +1,93 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// Register installs the middleware and every API route on r
+func (h *Handler) Register(r *gin.Engine) {
+	// Middleware
+	r.Use(requestID())
+	r.Use(requestLogger())
+	r.Use(requestMetrics())
+	r.Use(requestTimeout())
+	r.Use(gin.Recovery())
+	r.Use(cors())
+	r.Use(requireAPIKeyForWrites())
+
+	// Routes
+	// Entries belong to the user named by X-User-ID
+	entryRoutes := r.Group("/entries", requireUserID())
+	entryRoutes.GET("", h.getEntries) // ?format=simple for clean response
+	entryRoutes.GET("/:id", h.getEntryByID)
+	entryRoutes.GET("/:id/drift", h.getEntryDrift)
+	entryRoutes.POST("/:id/refresh", h.refreshEntry)
+	entryRoutes.PUT("/:id", limitRequestBody(), restrictCreateFields(), h.updateEntry)
+	entryRoutes.PATCH("/:id", h.patchEntry)
+	entryRoutes.DELETE("/:id", h.deleteEntry)
+	entryRoutes.DELETE("", h.deleteEntries)
+	entryRoutes.POST("", limitRequestBody(), h.idempotent(), rateLimit(), dailyEntryQuota(), restrictCreateFields(), h.createEntry)
+	entryRoutes.POST("/transaction", limitRequestBody(), rateLimit(), dailyEntryQuota(), restrictCreateFields(), h.createEntriesTransaction)
+	entryRoutes.POST("/batch", limitRequestBody(), rateLimit(), dailyEntryQuota(), restrictCreateFields(), h.createEntriesBatch)
+	entryRoutes.POST("/compact", h.compactEntries)
+	entryRoutes.POST("/merge", limitRequestBody(), h.mergeEntries)
+	entryRoutes.GET("/running", h.getRunningEntries)
+	entryRoutes.GET("/export", h.exportEntries)
+	entryRoutes.POST("/backfill-meals", h.backfillMeals)
+	entryRoutes.POST("/from-recipe/:name", limitRequestBody(), rateLimit(), dailyEntryQuota(), restrictCreateFields(), h.createEntryFromRecipe)
+	entryRoutes.POST("/barcode/:upc", limitRequestBody(), rateLimit(), dailyEntryQuota(), restrictCreateFields(), h.createEntryFromBarcode)
+
+	// Food lookups
+	r.GET("/aliases", h.getAliases)
+	r.GET("/search", h.getSearch)
+
+	// Summaries, goals, weights, recipes and insights cover only the data of the user
+	userRoutes := r.Group("", requireUserID())
+	userRoutes.GET("/recipes", h.getRecipes)
+	userRoutes.POST("/recipes", h.createRecipe)
+	userRoutes.GET("/summary", h.getSummary)
+	userRoutes.GET("/summary/by-tag", h.getSummaryByTag)
+	userRoutes.GET("/summary/overage", h.getSummaryOverage)
+	userRoutes.GET("/summary/without", h.getSummaryWithout)
+	userRoutes.GET("/summary/:date/progress", h.getGoalProgress)
+
+	// Planning
+	userRoutes.GET("/plan/remaining", h.getRemainingPlan)
+	userRoutes.GET("/recommend/protein", h.getProteinRecommendation)
+
+	// Weights
+	userRoutes.GET("/weights", h.getWeights)
+	userRoutes.POST("/weights", h.createWeight)
+
+	// Export
+	userRoutes.GET("/export/all", requireAPIKey(), h.exportAll)
+
+	// Goals
+	userRoutes.GET("/goals", h.getGoals)
+	userRoutes.PUT("/goals", h.putGoals)
+	userRoutes.POST("/goals/from-split", h.putGoalsFromSplit)
+
+	// Insights
+	userRoutes.GET("/insights/logging-times", h.getLoggingTimes)
+	userRoutes.GET("/insights/mood-by-food", h.getMoodByFood)
+	userRoutes.GET("/insights/longest-gap", h.getLongestGap)
+	userRoutes.GET("/insights/weekly-balance", h.getWeeklyBalance)
+	userRoutes.GET("/insights/duplicates", h.getDuplicates)
+	userRoutes.GET("/insights/contributors", h.getContributors)
+	userRoutes.GET("/insights/goal-forecast", h.getGoalForecast)
+	userRoutes.GET("/insights/sparkline", h.getSparkline)
+	userRoutes.GET("/insights/dining-split", h.getDiningSplit)
+	userRoutes.GET("/insights/best-meal", h.getBestMeal)
+	userRoutes.GET("/insights/protein-target", h.getProteinTarget)
+	userRoutes.GET("/insights/variety", h.getVariety)
+	userRoutes.GET("/insights/rolling-average", h.getRollingAverage)
+	userRoutes.GET("/insights/consistency", h.getConsistency)
+	userRoutes.GET("/insights/meal-distribution", h.getMealDistribution)
+	userRoutes.GET("/insights/extremes", h.getExtremes)
+	userRoutes.GET("/insights/fiber", h.getFiber)
+	userRoutes.GET("/insights/weekend-effect", h.getWeekendEffect)
+	userRoutes.GET("/insights/footprint", h.getFootprint)
+
+	// Health check
+	r.GET("/health", h.getHealth)
+	r.GET("/health/live", h.getLiveness)
+	r.GET("/health/credentials", requireAPIKey(), h.getCredentialsHealth)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fierda/go_nutrition/nutritionix"
+	"github.com/gin-gonic/gin"
+)
+
+// Bounds of GET /search; autocomplete gives up sooner than other Nutritionix calls
+const (
+	minSearchQueryLen = 2
+	maxSearchResults  = 20
+	searchTimeout     = 10 * time.Second
+)
+
+// SearchResult represents a food candidate from Nutritionix instant search
+type SearchResult struct {
+	FoodName  string `json:"food_name" example:"grilled chicken"`
+	BrandName string `json:"brand_name,omitempty" example:"Tyson"`
+	Type      string `json:"type" example:"common" enums:"common,branded"`
+	Thumbnail string `json:"thumbnail,omitempty" example:"https://nix-tag-images.s3.amazonaws.com/1562_thumb.jpg"`
+}
+
+// searchFoods returns up to maxSearchResults candidates for a partial query,
+// common foods first
+func (h *Handler) searchFoods(ctx context.Context, query string) ([]SearchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	found, err := h.nutritionix.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	add := func(foods []nutritionix.SearchFood, kind string) {
+		for _, food := range foods {
+			if len(results) == maxSearchResults {
+				return
+			}
+			results = append(results, SearchResult{
+				FoodName:  food.FoodName,
+				BrandName: food.BrandName,
+				Type:      kind,
+				Thumbnail: food.Photo.Thumb,
+			})
+		}
+	}
+	add(found.Common, "common")
+	add(found.Branded, "branded")
+	return results, nil
+}
+
+// GetSearch godoc
+// @Summary Search foods
+// @Description Autocomplete candidate foods from Nutritionix instant search without creating an entry; common foods come before branded ones, at most 20 results
+// @Tags search
+// @Produce json
+// @Param q query string true "Partial food name (at least 2 characters)"
+// @Success 200 {array} SearchResult
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /search [get]
+func (h *Handler) getSearch(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(q) < minSearchQueryLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("q must be at least %d characters", minSearchQueryLen)})
+		return
+	}
+
+	results, err := h.searchFoods(c.Request.Context(), q)
+	countNutritionixCall(err)
+	if err != nil {
+		logNutritionixError(c.Request.Context(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search foods"})
+		return
+	}
+
+	respond(c, http.StatusOK, results)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import "fierda/go_nutrition/store"
+
+// Entry Storage
+//
+// Handlers reach the entries only through the Handler's store.Store, so tests
+// can swap in a fake; the helpers below keep call sites short
+
+// Entry is defined by the store package
+type Entry = store.Entry
+
+var (
+	errDuplicateClientID = store.ErrDuplicateClientID
+	errEntryNotFound     = store.ErrEntryNotFound
+	errStoreNotEmpty     = store.ErrStoreNotEmpty
+)
+
+// insertEntries assigns IDs and stores all entries in a single transaction;
+// nothing is stored if any client_id is already taken
+func (h *Handler) insertEntries(entries []Entry) ([]Entry, error) {
+	return h.store.Create(entries)
+}
+
+// updateEntries runs fn with the current entries, which it must not modify;
+// the entries it returns are saved and the IDs it returns are deleted in a
+// single transaction. An error from fn aborts without changes
+func (h *Handler) updateEntries(fn func(current map[int]Entry) (save []Entry, remove []int, err error)) error {
+	return h.store.Update(fn)
+}
+
+// modifyEntry applies fn to a stored entry and saves the result
+func (h *Handler) modifyEntry(id int, fn func(entry *Entry)) (Entry, error) {
+	var entry Entry
+	err := h.updateEntries(func(current map[int]Entry) ([]Entry, []int, error) {
+		var exists bool
+		if entry, exists = current[id]; !exists {
+			return nil, nil, errEntryNotFound
+		}
+		fn(&entry)
+		return []Entry{entry}, nil, nil
+	})
+	return entry, err
+}
+
+// removeEntry deletes an entry and releases its client_id
+func (h *Handler) removeEntry(id int) error {
+	return h.store.Delete(id)
+}
+
+// resetEntryIDs restarts the ID sequence at 1; only allowed while the store is empty
+func (h *Handler) resetEntryIDs() error {
+	return h.store.ResetIDs()
+}
+
+// getEntry returns a stored entry by ID
+func (h *Handler) getEntry(id int) (Entry, bool) {
+	return h.store.Get(id)
+}
+
+// countEntries returns the number of stored entries
+func (h *Handler) countEntries() int {
+	return h.store.Count()
+}
+
+// clientIDExists reports whether an entry of userID already uses the client_id
+func (h *Handler) clientIDExists(userID, clientID string) bool {
+	return h.store.ClientIDExists(userID, clientID)
+}
+
+// snapshotEntries returns a copy of all stored entries ordered by ID
+func (h *Handler) snapshotEntries() []Entry {
+	return h.store.List()
+}
+
+// getUserEntry returns a stored entry by ID when it belongs to userID; entries
+// of other users are reported as missing
+func (h *Handler) getUserEntry(id int, userID string) (Entry, bool) {
+	entry, exists := h.getEntry(id)
+	if !exists || entry.UserID != userID {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// snapshotUserEntries returns a copy of the entries of userID ordered by ID
+func (h *Handler) snapshotUserEntries(userID string) []Entry {
+	entries := h.snapshotEntries()
+	owned := entries[:0]
+	for _, entry := range entries {
+		if entry.UserID == userID {
+			owned = append(owned, entry)
+		}
+	}
+	return owned
+}
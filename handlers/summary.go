@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Totals holds aggregated nutrient amounts
+type Totals struct {
+	Calories float64 `json:"calories" example:"1850.5"`
+	Protein  float64 `json:"protein_g" example:"95.2"`
+	Carbs    float64 `json:"carbs_g" example:"210.4"`
+	Fat      float64 `json:"fat_g" example:"60.1"`
+	Fiber    float64 `json:"fiber_g,omitempty" example:"21.4"`
+
+	// GlycemicLoad is an estimate, see glycemicLoad
+	GlycemicLoad float64 `json:"glycemic_load,omitempty" example:"92.4"`
+	// CO2Estimate is a rough estimate in kg CO2e, see co2Estimate
+	CO2Estimate float64 `json:"co2_estimate_kg,omitempty" example:"3.2"`
+}
+
+// AddFood accumulates a single food into the totals
+func (t *Totals) AddFood(food Food) {
+	t.Calories += food.NFCalories
+	t.Protein += food.NFProtein
+	t.Carbs += food.NFTotalCarbs
+	t.Fat += food.NFTotalFat
+	t.Fiber += food.NFDietaryFiber
+	t.GlycemicLoad += glycemicLoad(food)
+	t.CO2Estimate += co2Estimate(food)
+}
+
+// AddEntry accumulates every food of an entry into the totals
+func (t *Totals) AddEntry(entry Entry) {
+	for _, food := range entry.Nutrients.Foods {
+		t.AddFood(food)
+	}
+}
+
+// entryTotals returns the totals for a single entry
+func entryTotals(entry Entry) Totals {
+	var t Totals
+	t.AddEntry(entry)
+	return t
+}
+
+// dailyTotals returns the totals of every date userID logged
+func (h *Handler) dailyTotals(userID string) map[string]Totals {
+	days := make(map[string]Totals)
+	for _, entry := range h.snapshotUserEntries(userID) {
+		t := days[entry.Date]
+		t.AddEntry(entry)
+		days[entry.Date] = t
+	}
+	return days
+}
+
+// entriesOn returns the entries userID logged for a date, oldest first
+func (h *Handler) entriesOn(userID, date string) []Entry {
+	var day []Entry
+	for _, entry := range h.snapshotUserEntries(userID) {
+		if entry.Date == date {
+			day = append(day, entry)
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool { return day[i].CreatedAt.Before(day[j].CreatedAt) })
+	return day
+}
+
+// requireDate reads and validates a mandatory date query param
+func requireDate(c *gin.Context) (string, bool) {
+	date := c.Query("date")
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required in YYYY-MM-DD format"})
+		return "", false
+	}
+	return date, true
+}
+
+// MealSummary represents the totals of one meal category on a date
+type MealSummary struct {
+	Meal    string `json:"meal" example:"lunch" enums:"breakfast,lunch,dinner,snack,uncategorized"`
+	Entries int    `json:"entries" example:"2"`
+	Totals
+}
+
+// DailySummary represents the totals of all entries logged on a date
+type DailySummary struct {
+	Date    string `json:"date" example:"2025-08-11"`
+	Entries int    `json:"entries" example:"4"`
+	Totals
+
+	// Meals breaks the totals down by meal category, every category in display order
+	Meals []MealSummary `json:"meals"`
+
+	// Weather is only set when requested and the weather integration is configured
+	Weather *DayWeather `json:"weather,omitempty"`
+}
+
+// GetSummary godoc
+// @Summary Get daily totals
+// @Description Calorie and macro totals per logged date, newest first, each broken down by meal (breakfast, lunch, dinner, snack, uncategorized); with weather=true each of the 31 most recent days includes the weather at WEATHER_LAT/WEATHER_LON when the weather integration is configured, and is returned without it when the lookup fails
+// @Tags summary
+// @Produce json
+// @Param date query string false "Only include this date" format(date)
+// @Param weather query bool false "Include each day's weather"
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} DailySummary
+// @Failure 400 {object} ErrorResponse
+// @Router /summary [get]
+func (h *Handler) getSummary(c *gin.Context) {
+	date := c.Query("date")
+	if date != "" {
+		var ok bool
+		if date, ok = requireDate(c); !ok {
+			return
+		}
+	}
+
+	byDate := make(map[string]*DailySummary)
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if date != "" && entry.Date != date {
+			continue
+		}
+		s := byDate[entry.Date]
+		if s == nil {
+			s = &DailySummary{Date: entry.Date, Meals: make([]MealSummary, len(mealCategories))}
+			for i, meal := range mealCategories {
+				s.Meals[i].Meal = meal
+			}
+			byDate[entry.Date] = s
+		}
+		s.Entries++
+		s.AddEntry(entry)
+		meal := &s.Meals[slices.Index(mealCategories, mealOf(entry))]
+		meal.Entries++
+		meal.AddEntry(entry)
+	}
+
+	withWeather := c.Query("weather") == "true" && weatherConfigured()
+	result := make([]DailySummary, 0, len(byDate))
+	for _, s := range byDate {
+		s.Totals = s.Totals.Rounded()
+		for i := range s.Meals {
+			s.Meals[i].Totals = s.Meals[i].Totals.Rounded()
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date > result[j].Date })
+
+	if withWeather {
+		dates := make([]string, 0, min(len(result), maxWeatherDays))
+		for i := 0; i < len(result) && i < maxWeatherDays; i++ {
+			dates = append(dates, result[i].Date)
+		}
+		weather := fetchWeatherDays(c.Request.Context(), dates)
+		for i := range result {
+			if w, ok := weather[result[i].Date]; ok {
+				result[i].Weather = &w
+			}
+		}
+	}
+
+	respond(c, http.StatusOK, result)
+}
+
+// RunningEntry represents an entry with the day's running calorie tally
+type RunningEntry struct {
+	ID                 int       `json:"id" example:"1"`
+	Query              string    `json:"query" example:"1 cup rice"`
+	Calories           float64   `json:"calories" example:"205.4"`
+	CumulativeCalories float64   `json:"cumulative_calories" example:"705.4"`
+	RemainingCalories  float64   `json:"remaining_calories" example:"1294.6"`
+	CreatedAt          time.Time `json:"created_at" example:"2025-08-11T10:00:00Z"`
+}
+
+// RunningResponse represents the running calorie tally for a day
+type RunningResponse struct {
+	Date         string         `json:"date" example:"2025-08-11"`
+	GoalCalories float64        `json:"goal_calories" example:"2000"`
+	Entries      []RunningEntry `json:"entries"`
+}
+
+// GetRunningEntries godoc
+// @Summary Get running calorie tally
+// @Description Get a day's entries in logging order with the cumulative calories and remaining budget after each
+// @Tags entries
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} RunningResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /entries/running [get]
+func (h *Handler) getRunningEntries(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No calorie goal set"})
+		return
+	}
+
+	resp := RunningResponse{Date: date, GoalCalories: g.Calories, Entries: []RunningEntry{}}
+	var cumulative float64
+	for _, entry := range h.entriesOn(userIDFrom(c), date) {
+		calories := entryTotals(entry).Calories
+		cumulative += calories
+		resp.Entries = append(resp.Entries, RunningEntry{
+			ID:                 entry.ID,
+			Query:              entry.Query,
+			Calories:           calories,
+			CumulativeCalories: cumulative,
+			RemainingCalories:  g.Calories - cumulative,
+			CreatedAt:          entry.CreatedAt,
+		})
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// untaggedBucket groups entries that carry no tags
+const untaggedBucket = "untagged"
+
+// TagSummary represents the totals of entries carrying a tag
+type TagSummary struct {
+	Tag     string `json:"tag" example:"home"`
+	Entries int    `json:"entries" example:"3"`
+	Totals
+}
+
+// GetSummaryByTag godoc
+// @Summary Get totals by tag
+// @Description Calorie and macro totals per tag; entries with several tags count toward each, entries without tags go to "untagged"
+// @Tags summary
+// @Produce json
+// @Param date query string false "Only include this date" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {array} TagSummary
+// @Failure 400 {object} ErrorResponse
+// @Router /summary/by-tag [get]
+func (h *Handler) getSummaryByTag(c *gin.Context) {
+	date := c.Query("date")
+	if date != "" {
+		var ok bool
+		if date, ok = requireDate(c); !ok {
+			return
+		}
+	}
+
+	byTag := make(map[string]*TagSummary)
+	add := func(tag string, entry Entry) {
+		s := byTag[tag]
+		if s == nil {
+			s = &TagSummary{Tag: tag}
+			byTag[tag] = s
+		}
+		s.Entries++
+		s.AddEntry(entry)
+	}
+	for _, entry := range h.snapshotUserEntries(userIDFrom(c)) {
+		if date != "" && entry.Date != date {
+			continue
+		}
+		if len(entry.Tags) == 0 {
+			add(untaggedBucket, entry)
+		}
+		for _, tag := range entry.Tags {
+			add(tag, entry)
+		}
+	}
+
+	result := make([]TagSummary, 0, len(byTag))
+	for _, s := range byTag {
+		s.Totals = s.Totals.Rounded()
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].Tag == untaggedBucket) != (result[j].Tag == untaggedBucket) {
+			return result[j].Tag == untaggedBucket
+		}
+		return result[i].Tag < result[j].Tag
+	})
+
+	respond(c, http.StatusOK, result)
+}
+
+// exerciseKcalPerMinute are rough burn rates for a 70 kg adult, used to
+// express a calorie overage as exercise time
+var exerciseKcalPerMinute = map[string]float64{
+	"running": 11.4,
+	"cycling": 8.8,
+	"walking": 4.1,
+}
+
+// OverageResponse represents how far a day went over the calorie goal
+type OverageResponse struct {
+	Date            string             `json:"date" example:"2025-08-11"`
+	GoalCalories    float64            `json:"goal_calories" example:"2000"`
+	Calories        float64            `json:"calories" example:"2342"`
+	OverCalories    float64            `json:"over_calories" example:"342"`
+	ExerciseMinutes map[string]float64 `json:"exercise_minutes"`
+}
+
+// GetSummaryOverage godoc
+// @Summary Get calorie overage
+// @Description Calories over the daily goal and the approximate exercise minutes that would offset them (estimates for a 70 kg adult); zeros when under goal
+// @Tags summary
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} OverageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /summary/overage [get]
+func (h *Handler) getSummaryOverage(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No calorie goal set"})
+		return
+	}
+
+	resp := OverageResponse{
+		Date:            date,
+		GoalCalories:    g.Calories,
+		Calories:        h.dailyTotals(userIDFrom(c))[date].Calories,
+		ExerciseMinutes: make(map[string]float64, len(exerciseKcalPerMinute)),
+	}
+	if resp.Calories > g.Calories {
+		resp.OverCalories = resp.Calories - g.Calories
+	}
+	resp.Calories = roundAmount(resp.Calories)
+	resp.OverCalories = roundAmount(resp.OverCalories)
+	for activity, rate := range exerciseKcalPerMinute {
+		resp.ExerciseMinutes[activity] = math.Ceil(resp.OverCalories / rate)
+	}
+
+	respond(c, http.StatusOK, resp)
+}
+
+// WithoutFoodResponse represents a day's totals with a food hypothetically removed
+type WithoutFoodResponse struct {
+	Date         string `json:"date" example:"2025-08-11"`
+	Food         string `json:"food" example:"fries"`
+	RemovedFoods int    `json:"removed_foods" example:"1"`
+	Actual       Totals `json:"actual"`
+	Without      Totals `json:"without"`
+}
+
+// GetSummaryWithout godoc
+// @Summary Get totals without a food
+// @Description Recompute a day's totals as if every food whose name matches (case-insensitive) was not eaten; nothing is deleted
+// @Tags summary
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param food query string true "Food name to leave out, e.g. fries"
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} WithoutFoodResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /summary/without [get]
+func (h *Handler) getSummaryWithout(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	food := strings.ToLower(strings.TrimSpace(c.Query("food")))
+	if food == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "food is required"})
+		return
+	}
+
+	resp := WithoutFoodResponse{Date: date, Food: food}
+	for _, entry := range h.entriesOn(userIDFrom(c), date) {
+		for _, f := range entry.Nutrients.Foods {
+			resp.Actual.AddFood(f)
+			if strings.Contains(strings.ToLower(f.FoodName), food) {
+				resp.RemovedFoods++
+				continue
+			}
+			resp.Without.AddFood(f)
+		}
+	}
+	resp.Actual = resp.Actual.Rounded()
+	resp.Without = resp.Without.Rounded()
+
+	respond(c, http.StatusOK, resp)
+}
+
+// MacroPercent represents each total as a percentage of its goal; a field is
+// null when that goal is zero
+type MacroPercent struct {
+	Calories *float64 `json:"calories_pct" example:"92.5"`
+	Protein  *float64 `json:"protein_pct" example:"71.3"`
+	Carbs    *float64 `json:"carbs_pct" example:"84.2"`
+	Fat      *float64 `json:"fat_pct" example:"92.5"`
+}
+
+// GoalProgressResponse represents a day's totals against the daily goals;
+// goals, remaining and percent_of_goal are null when no goals are set
+type GoalProgressResponse struct {
+	Date      string        `json:"date" example:"2025-08-11"`
+	Entries   int           `json:"entries" example:"4"`
+	Totals    Totals        `json:"totals"`
+	Goals     *Goal         `json:"goals"`
+	Remaining *Totals       `json:"remaining"`
+	Percent   *MacroPercent `json:"percent_of_goal"`
+}
+
+// pctOfGoal returns actual as a percentage of goal, or nil when goal is zero
+func pctOfGoal(actual, goal float64) *float64 {
+	if goal <= 0 {
+		return nil
+	}
+	pct := roundAmount(100 * actual / goal)
+	return &pct
+}
+
+// GetGoalProgress godoc
+// @Summary Get progress toward daily goals
+// @Description A day's calorie and macro totals next to the daily goals, with what remains (zero once a goal is reached) and the percentage of each goal; when no goals are set the totals are returned with null goals
+// @Tags summary
+// @Produce json
+// @Param date path string true "Date" format(date)
+// @Param energy query string false "Report energy in kilojoules instead of kcal (calorie fields are renamed to kj)" Enums(kcal, kj)
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} GoalProgressResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /summary/{date}/progress [get]
+func (h *Handler) getGoalProgress(c *gin.Context) {
+	date := c.Param("date")
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	resp := GoalProgressResponse{Date: date}
+	var totals Totals
+	for _, entry := range h.entriesOn(userIDFrom(c), date) {
+		totals.AddEntry(entry)
+		resp.Entries++
+	}
+	resp.Totals = Totals{
+		Calories: totals.Calories,
+		Protein:  totals.Protein,
+		Carbs:    totals.Carbs,
+		Fat:      totals.Fat,
+	}.Rounded()
+
+	g, ok, err := h.currentGoal(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load goals"})
+		return
+	}
+	if ok {
+		resp.Goals = &g
+		resp.Remaining = &Totals{
+			Calories: roundAmount(math.Max(g.Calories-totals.Calories, 0)),
+			Protein:  roundAmount(math.Max(g.Protein-totals.Protein, 0)),
+			Carbs:    roundAmount(math.Max(g.Carbs-totals.Carbs, 0)),
+			Fat:      roundAmount(math.Max(g.Fat-totals.Fat, 0)),
+		}
+		resp.Percent = &MacroPercent{
+			Calories: pctOfGoal(totals.Calories, g.Calories),
+			Protein:  pctOfGoal(totals.Protein, g.Protein),
+			Carbs:    pctOfGoal(totals.Carbs, g.Carbs),
+			Fat:      pctOfGoal(totals.Fat, g.Fat),
+		}
+	}
+
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDHeader names the user whose entries a request reads and writes
+const userIDHeader = "X-User-ID"
+
+// userIDPattern bounds user IDs so they are safe to store and log
+var userIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requireUserID rejects requests without a well-formed X-User-ID header and
+// stores the user ID for userIDFrom
+func requireUserID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(userIDHeader)
+		if id == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header is required"})
+			return
+		}
+		if !userIDPattern.MatchString(id) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "X-User-ID must be 1 to 64 letters, digits, '.', '_' or '-'"})
+			return
+		}
+
+		c.Set("user_id", id)
+		c.Next()
+	}
+}
+
+// userIDFrom returns the user ID set by requireUserID
+func userIDFrom(c *gin.Context) string {
+	return c.GetString("user_id")
+}
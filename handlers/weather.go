@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Weather integration settings; enrichment is skipped unless all are set
+var (
+	weatherAPIKey string
+	weatherLat    string
+	weatherLon    string
+)
+
+// DayWeather represents the weather of a day at the configured location
+type DayWeather struct {
+	TempMinC        float64 `json:"temp_min_c" example:"24.1"`
+	TempMaxC        float64 `json:"temp_max_c" example:"31.6"`
+	PrecipitationMM float64 `json:"precipitation_mm" example:"4.2"`
+	HumidityPct     float64 `json:"humidity_pct" example:"71"`
+	CloudCoverPct   float64 `json:"cloud_cover_pct" example:"40"`
+}
+
+// openWeatherDaySummary is the subset of the OpenWeatherMap day_summary response we use
+type openWeatherDaySummary struct {
+	Temperature struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	} `json:"temperature"`
+	Precipitation struct {
+		Total float64 `json:"total"`
+	} `json:"precipitation"`
+	Humidity struct {
+		Afternoon float64 `json:"afternoon"`
+	} `json:"humidity"`
+	CloudCover struct {
+		Afternoon float64 `json:"afternoon"`
+	} `json:"cloud_cover"`
+}
+
+// weatherClient is shared by every weather lookup
+var weatherClient = &http.Client{Timeout: 10 * time.Second}
+
+// Weather lookups of one /summary request: only the maxWeatherDays most recent
+// days are looked up, at most weatherConcurrency at a time
+const (
+	maxWeatherDays     = 31
+	weatherConcurrency = 4
+)
+
+// Weather cache; past days do not change, so successful lookups are kept for good
+var (
+	weatherMu    sync.RWMutex
+	weatherCache = make(map[string]DayWeather)
+)
+
+// weatherConfigured reports whether the weather integration has a key and location
+func weatherConfigured() bool {
+	return weatherAPIKey != "" && weatherLat != "" && weatherLon != ""
+}
+
+// validateWeatherLocation checks that WEATHER_LAT and WEATHER_LON are coordinates
+func validateWeatherLocation() error {
+	if weatherLat == "" && weatherLon == "" {
+		return nil
+	}
+	lat, err := strconv.ParseFloat(weatherLat, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return fmt.Errorf("invalid WEATHER_LAT %q", weatherLat)
+	}
+	lon, err := strconv.ParseFloat(weatherLon, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return fmt.Errorf("invalid WEATHER_LON %q", weatherLon)
+	}
+	return nil
+}
+
+// fetchWeather returns the weather for a date, cached once the date is in the past
+func fetchWeather(ctx context.Context, date string) (DayWeather, error) {
+	weatherMu.RLock()
+	w, ok := weatherCache[date]
+	weatherMu.RUnlock()
+	if ok {
+		return w, nil
+	}
+
+	params := url.Values{}
+	params.Set("lat", weatherLat)
+	params.Set("lon", weatherLon)
+	params.Set("date", date)
+	params.Set("units", "metric")
+	params.Set("appid", weatherAPIKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.openweathermap.org/data/3.0/onecall/day_summary?"+params.Encode(), nil)
+	if err != nil {
+		return DayWeather{}, err
+	}
+
+	resp, err := weatherClient.Do(req)
+	if err != nil {
+		// Drop the URL from the error, it carries the API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return DayWeather{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return DayWeather{}, fmt.Errorf("weather API error: status %d", resp.StatusCode)
+	}
+	var summary openWeatherDaySummary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return DayWeather{}, err
+	}
+
+	w = DayWeather{
+		TempMinC:        summary.Temperature.Min,
+		TempMaxC:        summary.Temperature.Max,
+		PrecipitationMM: summary.Precipitation.Total,
+		HumidityPct:     summary.Humidity.Afternoon,
+		CloudCoverPct:   summary.CloudCover.Afternoon,
+	}
+	if date < time.Now().Format(dateLayout) {
+		weatherMu.Lock()
+		weatherCache[date] = w
+		weatherMu.Unlock()
+	}
+	return w, nil
+}
+
+// fetchWeatherDays looks up the weather of dates in parallel, bounded by
+// weatherConcurrency; failed lookups are logged and left out of the result
+func fetchWeatherDays(ctx context.Context, dates []string) map[string]DayWeather {
+	var mu sync.Mutex
+	result := make(map[string]DayWeather, len(dates))
+	var g errgroup.Group
+	g.SetLimit(weatherConcurrency)
+	for _, date := range dates {
+		g.Go(func() error {
+			w, err := fetchWeather(ctx, date)
+			if err != nil {
+				log.Printf("Weather lookup for %s failed: %v", date, err)
+				return nil
+			}
+			mu.Lock()
+			result[date] = w
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+	return result
+}
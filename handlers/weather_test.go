@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// weatherTransport answers every weather lookup after a short delay and
+// records how many were in flight at once
+type weatherTransport struct {
+	mu              sync.Mutex
+	calls, inFlight int
+	maxInFlight     int
+}
+
+func (t *weatherTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.calls++
+	t.inFlight++
+	t.maxInFlight = max(t.maxInFlight, t.inFlight)
+	t.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	t.mu.Lock()
+	t.inFlight--
+	t.mu.Unlock()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"temperature":{"min":24,"max":31}}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestSummaryWeatherLookupsAreBounded(t *testing.T) {
+	defer func(key, lat, lon string, client *http.Client) {
+		weatherAPIKey, weatherLat, weatherLon, weatherClient = key, lat, lon, client
+		weatherMu.Lock()
+		weatherCache = make(map[string]DayWeather)
+		weatherMu.Unlock()
+	}(weatherAPIKey, weatherLat, weatherLon, weatherClient)
+	transport := &weatherTransport{}
+	weatherAPIKey, weatherLat, weatherLon = "key", "-6.2", "106.8"
+	weatherClient = &http.Client{Transport: transport}
+
+	r, _, _ := newTestRouter(t)
+	const days = maxWeatherDays + 9
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < days; i++ {
+		body := fmt.Sprintf(`{"query":"1 apple","date":"%s"}`, start.AddDate(0, 0, i).Format(dateLayout))
+		if w := serve(r, http.MethodPost, "/entries", "alice", body); w.Code != http.StatusCreated {
+			t.Fatalf("create status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	w := serve(r, http.MethodGet, "/summary?weather=true", "alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var summaries []DailySummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summaries); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range summaries {
+		if recent := i < maxWeatherDays; recent != (s.Weather != nil) {
+			t.Errorf("%s (day %d, newest first): weather = %v, want it only on the %d most recent days", s.Date, i, s.Weather, maxWeatherDays)
+		}
+	}
+	if transport.calls != maxWeatherDays {
+		t.Errorf("weather lookups = %d, want %d", transport.calls, maxWeatherDays)
+	}
+	if transport.maxInFlight > weatherConcurrency {
+		t.Errorf("%d lookups in flight at once, want at most %d", transport.maxInFlight, weatherConcurrency)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fierda/go_nutrition/store"
+	"github.com/gin-gonic/gin"
+)
+
+// WeightEntry is defined by the store package
+type WeightEntry = store.Weight
+
+// CreateWeightRequest represents the request body for logging a weight
+type CreateWeightRequest struct {
+	Date     string  `json:"date" binding:"required" example:"2025-08-11" format:"date"`
+	WeightKg float64 `json:"weight_kg" binding:"required,gt=0,lt=500" example:"72.5"`
+}
+
+// latestWeight returns the most recent weight userID logged on or before date
+func (h *Handler) latestWeight(userID, date string) (WeightEntry, bool, error) {
+	weights, err := h.store.Weights(userID)
+	if err != nil {
+		return WeightEntry{}, false, err
+	}
+	var latest WeightEntry
+	found := false
+	for _, w := range weights {
+		if w.Date > date {
+			break
+		}
+		latest, found = w, true
+	}
+	return latest, found, nil
+}
+
+// CreateWeight godoc
+// @Summary Log body weight
+// @Description Log the body weight for a date, replacing any earlier measurement of that date
+// @Tags weights
+// @Accept json
+// @Produce json
+// @Param weight body CreateWeightRequest true "Weight data"
+// @Param X-User-ID header string true "User whose weights are read or written"
+// @Success 201 {object} WeightEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /weights [post]
+func (h *Handler) createWeight(c *gin.Context) {
+	var req CreateWeightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := time.Parse(dateLayout, req.Date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date must be in YYYY-MM-DD format"})
+		return
+	}
+
+	w := WeightEntry{Date: req.Date, WeightKg: req.WeightKg, CreatedAt: time.Now()}
+	if err := h.store.SaveWeight(userIDFrom(c), w); err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save weight"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, w)
+}
+
+// GetWeights godoc
+// @Summary Get body weights
+// @Description Get all body weights the user logged, ordered by date
+// @Tags weights
+// @Produce json
+// @Param X-User-ID header string true "User whose weights are read or written"
+// @Success 200 {array} WeightEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /weights [get]
+func (h *Handler) getWeights(c *gin.Context) {
+	result, err := h.store.Weights(userIDFrom(c))
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load weights"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ProteinTargetResponse represents protein intake against a bodyweight-relative target
+type ProteinTargetResponse struct {
+	Date       string  `json:"date" example:"2025-08-11"`
+	WeightKg   float64 `json:"weight_kg" example:"72.5"`
+	WeightDate string  `json:"weight_date" example:"2025-08-10"`
+	GPerKg     float64 `json:"g_per_kg" example:"1.8"`
+	TargetG    float64 `json:"target_g" example:"130.5"`
+	ProteinG   float64 `json:"protein_g" example:"112.3"`
+	Met        bool    `json:"met" example:"false"`
+}
+
+// GetProteinTarget godoc
+// @Summary Get protein-per-kg adherence
+// @Description Compare a day's protein with a target relative to the latest body weight logged on or before that day
+// @Tags insights
+// @Produce json
+// @Param date query string true "Date" format(date)
+// @Param g_per_kg query number false "Grams of protein per kg of body weight (default 1.6, max 4)"
+// @Param X-User-ID header string true "User whose entries are read or written"
+// @Success 200 {object} ProteinTargetResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /insights/protein-target [get]
+func (h *Handler) getProteinTarget(c *gin.Context) {
+	date, ok := requireDate(c)
+	if !ok {
+		return
+	}
+	gPerKg, err := strconv.ParseFloat(c.DefaultQuery("g_per_kg", "1.6"), 64)
+	if err != nil || gPerKg <= 0 || gPerKg > 4 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "g_per_kg must be greater than 0 and at most 4"})
+		return
+	}
+	w, ok, err := h.latestWeight(userIDFrom(c), date)
+	if err != nil {
+		log.Printf("Storage error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load weights"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No weight logged on or before this date"})
+		return
+	}
+
+	resp := ProteinTargetResponse{
+		Date:       date,
+		WeightKg:   w.WeightKg,
+		WeightDate: w.Date,
+		GPerKg:     gPerKg,
+		TargetG:    roundAmount(w.WeightKg * gPerKg),
+		ProteinG:   roundAmount(h.dailyTotals(userIDFrom(c))[date].Protein),
+	}
+	resp.Met = resp.ProteinG >= resp.TargetG
+
+	respond(c, http.StatusOK, resp)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestProteinTargetUsesStoredWeight(t *testing.T) {
+	r, s, _ := newTestRouter(t)
+	for _, body := range []string{
+		`{"date":"2025-08-12","weight_kg":80}`,
+		`{"date":"2025-08-10","weight_kg":75}`,
+		`{"date":"2025-08-10","weight_kg":70}`,
+	} {
+		if w := serve(r, http.MethodPost, "/weights", "alice", body); w.Code != http.StatusCreated {
+			t.Fatalf("create weight status = %d: %s", w.Code, w.Body)
+		}
+	}
+	if weights, _ := s.Weights("alice"); len(weights) != 2 {
+		t.Fatalf("stored %d weights, want one per date", len(weights))
+	}
+
+	w := serve(r, http.MethodGet, "/insights/protein-target?date=2025-08-11&g_per_kg=2", "alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	var resp ProteinTargetResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.WeightDate != "2025-08-10" || resp.WeightKg != 70 || resp.TargetG != 140 {
+		t.Errorf("weight %v on %s, target %v, want 70 on 2025-08-10, target 140", resp.WeightKg, resp.WeightDate, resp.TargetG)
+	}
+
+	if w := serve(r, http.MethodGet, "/insights/protein-target?date=2025-08-11", "bob", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("user without weights status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
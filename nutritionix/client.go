@@ -0,0 +1,129 @@
+// Package nutritionix is a minimal client for the Nutritionix v2 API
+package nutritionix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// DefaultBaseURL is the Nutritionix v2 API
+const DefaultBaseURL = "https://trackapi.nutritionix.com/v2"
+
+// Classes of failed calls; a *StatusError matches one of them with errors.Is
+var (
+	ErrUnauthorized = errors.New("nutritionix rejected APP_ID/APP_KEY")
+	ErrNotFound     = errors.New("could not recognize that food")
+	ErrUpstream     = errors.New("nutritionix API error")
+)
+
+// StatusError is returned for a response other than 200
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("nutritionix API error: status %d", e.StatusCode)
+}
+
+// Is matches 401 and 403 to ErrUnauthorized, 404 to ErrNotFound and any other
+// status to ErrUpstream
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUpstream:
+		return !errors.Is(e, ErrUnauthorized) && !errors.Is(e, ErrNotFound)
+	}
+	return false
+}
+
+// Temporary reports whether a failed call may succeed when retried: network
+// errors, 429 and 5xx responses
+func Temporary(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Client calls Nutritionix with an app ID and key
+type Client struct {
+	AppID  string
+	AppKey string
+
+	// HTTP sends the requests; share one client so connections are reused
+	HTTP *http.Client
+
+	// BaseURL defaults to DefaultBaseURL
+	BaseURL string
+}
+
+// Nutrients parses a natural language query such as "1 cup rice" into foods
+func (c *Client) Nutrients(ctx context.Context, query string) (Response, error) {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	var resp Response
+	err := c.do(ctx, http.MethodPost, "/natural/nutrients", body, &resp)
+	return resp, err
+}
+
+// Search returns instant search candidates for a partial food name
+func (c *Client) Search(ctx context.Context, query string) (SearchResponse, error) {
+	var resp SearchResponse
+	err := c.do(ctx, http.MethodGet, "/search/instant?query="+url.QueryEscape(query), nil, &resp)
+	return resp, err
+}
+
+// Item looks up the packaged food of a UPC/EAN barcode
+func (c *Client) Item(ctx context.Context, upc string) ([]ItemFood, error) {
+	var resp struct {
+		Foods []ItemFood `json:"foods"`
+	}
+	err := c.do(ctx, http.MethodGet, "/search/item?upc="+url.QueryEscape(upc), nil, &resp)
+	return resp.Foods, err
+}
+
+// do sends an authenticated request and decodes a 200 response into out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, base+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-app-id", c.AppID)
+	req.Header.Set("x-app-key", c.AppKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
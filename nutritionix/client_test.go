@@ -0,0 +1,39 @@
+package nutritionix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNutrientsCanceledMidFlight(t *testing.T) {
+	received, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	c := &Client{HTTP: srv.Client(), BaseURL: srv.URL}
+	start := time.Now()
+	_, err := c.Nutrients(ctx, "1 cup rice")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Nutrients returned after %v, want it to stop on cancel", elapsed)
+	}
+}
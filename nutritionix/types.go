@@ -0,0 +1,59 @@
+package nutritionix
+
+// Response is the natural language nutrients response
+type Response struct {
+	Foods []Food `json:"foods"`
+}
+
+// Food is one food of a nutrients or item lookup response
+type Food struct {
+	FoodName            string   `json:"food_name" example:"rice"`
+	ServingQty          float64  `json:"serving_qty" example:"1"`
+	ServingUnit         string   `json:"serving_unit" example:"cup"`
+	ServingWeight       float64  `json:"serving_weight_grams" example:"158"`
+	NFCalories          float64  `json:"nf_calories" example:"205.4"`
+	NFProtein           float64  `json:"nf_protein" example:"4.25"`
+	NFTotalFat          float64  `json:"nf_total_fat" example:"0.44"`
+	NFTotalCarbs        float64  `json:"nf_total_carbohydrate" example:"44.51"`
+	NFSodium            float64  `json:"nf_sodium" example:"1.58"`
+	NFSugars            float64  `json:"nf_sugars" example:"0.08"`
+	NFDietaryFiber      float64  `json:"nf_dietary_fiber" example:"0.63"`
+	Photo               Photo    `json:"photo"`
+	Tags                FoodTags `json:"tags"`
+	IngredientStatement string   `json:"nf_ingredient_statement,omitempty"`
+
+	// AllergenTags is not sent by Nutritionix; callers fill it in from the
+	// food name and ingredients
+	AllergenTags []string `json:"allergen_tags,omitempty" example:"milk"`
+}
+
+// FoodTags holds the Nutritionix tag data for a food
+type FoodTags struct {
+	Item string `json:"item" example:"rice"`
+}
+
+// Photo holds the image URLs of a food
+type Photo struct {
+	Thumb   string `json:"thumb" example:"https://nix-tag-images.s3.amazonaws.com/784_thumb.jpg"`
+	Highres string `json:"highres" example:"https://nix-tag-images.s3.amazonaws.com/784_highres.jpg"`
+}
+
+// SearchFood is a candidate food from instant search
+type SearchFood struct {
+	FoodName  string `json:"food_name"`
+	BrandName string `json:"brand_name"`
+	Photo     Photo  `json:"photo"`
+}
+
+// SearchResponse is the instant search response
+type SearchResponse struct {
+	Common  []SearchFood `json:"common"`
+	Branded []SearchFood `json:"branded"`
+}
+
+// ItemFood is a packaged food from the item lookup; it carries the brand on
+// top of the Food fields
+type ItemFood struct {
+	Food
+	BrandName string `json:"brand_name"`
+}
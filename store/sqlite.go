@@ -0,0 +1,500 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// entriesSchema stores each entry as JSON in data; the id column is the source
+// of truth for the entry ID, and AUTOINCREMENT keeps IDs from being reused. A
+// client_id only has to be unique among the entries of one user
+const entriesSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id   TEXT NOT NULL DEFAULT '',
+	date      TEXT NOT NULL,
+	client_id TEXT,
+	data      TEXT NOT NULL,
+	UNIQUE (user_id, client_id)
+)`
+
+// goalsSchema keeps the daily goals of each user
+const goalsSchema = `
+CREATE TABLE IF NOT EXISTS goals (
+	user_id              TEXT PRIMARY KEY,
+	calories             REAL NOT NULL,
+	protein_g            REAL NOT NULL,
+	carbs_g              REAL NOT NULL,
+	fat_g                REAL NOT NULL,
+	maintenance_calories REAL NOT NULL
+)`
+
+// weightsSchema keeps one body weight per user and date
+const weightsSchema = `
+CREATE TABLE IF NOT EXISTS weights (
+	user_id    TEXT NOT NULL,
+	date       TEXT NOT NULL,
+	weight_kg  REAL NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (user_id, date)
+)`
+
+// recipesSchema stores each recipe as JSON in data, keyed by user and
+// normalized name
+const recipesSchema = `
+CREATE TABLE IF NOT EXISTS recipes (
+	user_id TEXT NOT NULL,
+	key     TEXT NOT NULL,
+	data    TEXT NOT NULL,
+	PRIMARY KEY (user_id, key)
+)`
+
+// clientKey identifies a client_id within the entries of one user
+type clientKey struct {
+	userID   string
+	clientID string
+}
+
+// SQLite persists entries, goals, weights and recipes in a SQLite database;
+// entries is a cache of the entries table that is loaded on open and written
+// through on every change while mu is held. Goals, weights and recipes are read
+// from the database directly
+type SQLite struct {
+	db        *sql.DB
+	mu        sync.RWMutex
+	entries   map[int]Entry
+	clientIDs map[clientKey]int // user and client_id -> entry ID
+}
+
+// Open opens the SQLite database at path, creating the schema if needed, and
+// loads all entries into the cache
+func Open(path string) (*SQLite, error) {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serializes writes and keeps :memory: databases shared
+	conn.SetMaxOpenConns(1)
+	for _, schema := range []string{entriesSchema, goalsSchema, weightsSchema, recipesSchema} {
+		if _, err := conn.Exec(schema); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := migrateClientIDs(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	loaded, err := loadEntries(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	s := &SQLite{db: conn, entries: loaded, clientIDs: make(map[clientKey]int)}
+	for id, entry := range loaded {
+		if entry.ClientID != "" {
+			s.clientIDs[clientKeyOf(entry)] = id
+		}
+	}
+	return s, nil
+}
+
+// migrateClientIDs rebuilds an entries table from before user_id was a column,
+// where client_id was unique across all users; the ID sequence is kept
+func migrateClientIDs(conn *sql.DB) error {
+	rows, err := conn.Query("SELECT name FROM pragma_table_info('entries')")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == "user_id" {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seq sql.NullInt64
+	err = tx.QueryRow("SELECT seq FROM sqlite_sequence WHERE name = 'entries'").Scan(&seq)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	for _, stmt := range []string{
+		"ALTER TABLE entries RENAME TO entries_old",
+		entriesSchema,
+		`INSERT INTO entries (id, user_id, date, client_id, data)
+			SELECT id, COALESCE(json_extract(data, '$.user_id'), ''), date, client_id, data FROM entries_old`,
+		"DROP TABLE entries_old",
+		"DELETE FROM sqlite_sequence WHERE name = 'entries'",
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if seq.Valid {
+		if _, err := tx.Exec("INSERT INTO sqlite_sequence (name, seq) VALUES ('entries', ?)", seq.Int64); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// loadEntries reads every row of the entries table
+func loadEntries(conn *sql.DB) (map[int]Entry, error) {
+	rows, err := conn.Query("SELECT id, data FROM entries")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loaded := make(map[int]Entry)
+	for rows.Next() {
+		var id int
+		var data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, err
+		}
+		entry.ID = id
+		// Entries saved before meals were always set have none
+		if entry.Meal == "" {
+			entry.Meal = MealUncategorized
+		}
+		loaded[id] = entry
+	}
+	return loaded, rows.Err()
+}
+
+// clientKeyOf returns the key of the client_id of entry
+func clientKeyOf(entry Entry) clientKey {
+	return clientKey{userID: entry.UserID, clientID: entry.ClientID}
+}
+
+// nullableClientID maps an empty client_id to NULL so the UNIQUE constraint
+// only applies to entries that set one
+func nullableClientID(clientID string) sql.NullString {
+	return sql.NullString{String: clientID, Valid: clientID != ""}
+}
+
+// Create assigns IDs and stores all entries in a single transaction; nothing
+// is stored if any client_id is already taken by the same user
+func (s *SQLite) Create(entries []Entry) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[clientKey]bool)
+	for _, entry := range entries {
+		if entry.ClientID == "" {
+			continue
+		}
+		key := clientKeyOf(entry)
+		if _, exists := s.clientIDs[key]; exists || seen[key] {
+			return nil, ErrDuplicateClientID
+		}
+		seen[key] = true
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for i := range entries {
+		data, err := json.Marshal(entries[i])
+		if err != nil {
+			return nil, err
+		}
+		res, err := tx.Exec("INSERT INTO entries (user_id, date, client_id, data) VALUES (?, ?, ?, ?)",
+			entries[i].UserID, entries[i].Date, nullableClientID(entries[i].ClientID), string(data))
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		entries[i].ID = int(id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		s.entries[entry.ID] = entry
+		if entry.ClientID != "" {
+			s.clientIDs[clientKeyOf(entry)] = entry.ID
+		}
+	}
+	return entries, nil
+}
+
+// Update runs fn under the write lock with the current entries; the entries it
+// returns are saved and the IDs it returns are deleted in a single transaction
+func (s *SQLite) Update(fn UpdateFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	save, remove, err := fn(s.entries)
+	if err != nil {
+		return err
+	}
+	if len(save) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range save {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE entries SET user_id = ?, date = ?, client_id = ?, data = ? WHERE id = ?",
+			entry.UserID, entry.Date, nullableClientID(entry.ClientID), string(data), entry.ID); err != nil {
+			return err
+		}
+	}
+	for _, id := range remove {
+		if _, err := tx.Exec("DELETE FROM entries WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, entry := range save {
+		if old, exists := s.entries[entry.ID]; exists && old.ClientID != "" {
+			delete(s.clientIDs, clientKeyOf(old))
+		}
+		s.entries[entry.ID] = entry
+		if entry.ClientID != "" {
+			s.clientIDs[clientKeyOf(entry)] = entry.ID
+		}
+	}
+	for _, id := range remove {
+		if entry, exists := s.entries[id]; exists && entry.ClientID != "" {
+			delete(s.clientIDs, clientKeyOf(entry))
+		}
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+// Delete removes an entry and releases its client_id
+func (s *SQLite) Delete(id int) error {
+	return s.Update(func(current map[int]Entry) ([]Entry, []int, error) {
+		if _, exists := current[id]; !exists {
+			return nil, nil, ErrEntryNotFound
+		}
+		return nil, []int{id}, nil
+	})
+}
+
+// ResetIDs restarts the ID sequence at 1; only allowed while the store is empty
+func (s *SQLite) ResetIDs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) > 0 {
+		return ErrStoreNotEmpty
+	}
+	_, err := s.db.Exec("DELETE FROM sqlite_sequence WHERE name = 'entries'")
+	return err
+}
+
+// Get returns a stored entry by ID
+func (s *SQLite) Get(id int) (Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, exists := s.entries[id]
+	return entry, exists
+}
+
+// Count returns the number of stored entries
+func (s *SQLite) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.entries)
+}
+
+// ClientIDExists reports whether an entry of userID already uses the client_id
+func (s *SQLite) ClientIDExists(userID, clientID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.clientIDs[clientKey{userID: userID, clientID: clientID}]
+	return exists
+}
+
+// List returns a copy of all stored entries ordered by ID
+func (s *SQLite) List() []Entry {
+	s.mu.RLock()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+// SaveGoal stores the goal of userID, replacing any previous one
+func (s *SQLite) SaveGoal(userID string, g Goal) error {
+	_, err := s.db.Exec(`INSERT INTO goals (user_id, calories, protein_g, carbs_g, fat_g, maintenance_calories) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET calories = excluded.calories, protein_g = excluded.protein_g,
+			carbs_g = excluded.carbs_g, fat_g = excluded.fat_g, maintenance_calories = excluded.maintenance_calories`,
+		userID, g.Calories, g.Protein, g.Carbs, g.Fat, g.Maintenance)
+	return err
+}
+
+// GetGoal returns the goal of userID
+func (s *SQLite) GetGoal(userID string) (Goal, bool, error) {
+	var g Goal
+	err := s.db.QueryRow("SELECT calories, protein_g, carbs_g, fat_g, maintenance_calories FROM goals WHERE user_id = ?", userID).
+		Scan(&g.Calories, &g.Protein, &g.Carbs, &g.Fat, &g.Maintenance)
+	if err == sql.ErrNoRows {
+		return Goal{}, false, nil
+	}
+	if err != nil {
+		return Goal{}, false, err
+	}
+	return g, true, nil
+}
+
+// SaveWeight stores a weight of userID, replacing any of the same date
+func (s *SQLite) SaveWeight(userID string, w Weight) error {
+	_, err := s.db.Exec(`INSERT INTO weights (user_id, date, weight_kg, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, date) DO UPDATE SET weight_kg = excluded.weight_kg, created_at = excluded.created_at`,
+		userID, w.Date, w.WeightKg, w.CreatedAt.Format(time.RFC3339Nano))
+	return err
+}
+
+// Weights returns the weights of userID ordered by date
+func (s *SQLite) Weights(userID string) ([]Weight, error) {
+	rows, err := s.db.Query("SELECT date, weight_kg, created_at FROM weights WHERE user_id = ? ORDER BY date", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := []Weight{}
+	for rows.Next() {
+		var w Weight
+		var createdAt string
+		if err := rows.Scan(&w.Date, &w.WeightKg, &createdAt); err != nil {
+			return nil, err
+		}
+		if w.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, err
+		}
+		weights = append(weights, w)
+	}
+	return weights, rows.Err()
+}
+
+// CreateRecipe stores a recipe of userID under key; ErrRecipeExists is
+// returned when the key is taken
+func (s *SQLite) CreateRecipe(userID, key string, r Recipe) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec("INSERT INTO recipes (user_id, key, data) VALUES (?, ?, ?) ON CONFLICT DO NOTHING", userID, key, string(data))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRecipeExists
+	}
+	return nil
+}
+
+// GetRecipe returns the recipe of userID stored under key
+func (s *SQLite) GetRecipe(userID, key string) (Recipe, bool, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM recipes WHERE user_id = ? AND key = ?", userID, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return Recipe{}, false, nil
+	}
+	if err != nil {
+		return Recipe{}, false, err
+	}
+	var r Recipe
+	if err := json.Unmarshal([]byte(data), &r); err != nil {
+		return Recipe{}, false, err
+	}
+	return r, true, nil
+}
+
+// Recipes returns the recipes of userID ordered by name
+func (s *SQLite) Recipes(userID string) ([]Recipe, error) {
+	rows, err := s.db.Query("SELECT data FROM recipes WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := []Recipe{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var r Recipe
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].Name < recipes[j].Name })
+	return recipes, nil
+}
+
+// Ping checks the database connection
+func (s *SQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
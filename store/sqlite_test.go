@@ -0,0 +1,44 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteDoesNotReuseDeletedIDsAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nutrition.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := s.Create([]Entry{
+		{UserID: "alice", Date: "2025-08-11", Query: "1 apple"},
+		{UserID: "alice", Date: "2025-08-11", Query: "1 banana"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := created[len(created)-1].ID
+	if err := s.Delete(last); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, exists := s.Get(last); exists {
+		t.Fatalf("entry %d is back after reopening", last)
+	}
+	created, err = s.Create([]Entry{{UserID: "alice", Date: "2025-08-12", Query: "1 pear"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := created[0].ID; id <= last {
+		t.Errorf("new entry got ID %d, want one above the deleted %d", id, last)
+	}
+}
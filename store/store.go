@@ -0,0 +1,116 @@
+// Package store persists nutrition entries
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fierda/go_nutrition/nutritionix"
+)
+
+// Entry is a stored nutrition entry
+type Entry struct {
+	ID              int                  `json:"id" example:"1"`
+	UserID          string               `json:"user_id" example:"alice"`
+	Date            string               `json:"date" example:"2025-08-11"`
+	Query           string               `json:"query" example:"1 cup Rice"`
+	NormalizedQuery string               `json:"normalized_query,omitempty" example:"1 cup rice"`
+	Nutrients       nutritionix.Response `json:"nutrients"`
+	Servings        float64              `json:"servings,omitempty" example:"1.5"` // Nutrients are already scaled by it; omitted when 1
+	Mood            int                  `json:"mood,omitempty" example:"4"`
+	Energy          int                  `json:"energy,omitempty" example:"3"`
+	Tags            []string             `json:"tags,omitempty" example:"home"`
+	Meal            string               `json:"meal" example:"lunch"`
+	ClientID        string               `json:"client_id,omitempty" example:"3f2b8c1e-9a4d-4f6b-8c2e-1d5a7b9c0e12"`
+	GroupID         string               `json:"group_id,omitempty" example:"9f86d081884c7d65"`
+	Truncated       bool                 `json:"foods_truncated,omitempty" example:"false"`
+	Source          string               `json:"source,omitempty" example:"recipe" enums:"recipe,barcode,merge"` // How the entry was built; omitted when logged from a natural-language query
+	Latitude        *float64             `json:"latitude,omitempty" example:"-6.2088"`
+	Longitude       *float64             `json:"longitude,omitempty" example:"106.8456"`
+	CreatedAt       time.Time            `json:"created_at" example:"2025-08-11T10:00:00Z"`
+	UpdatedAt       time.Time            `json:"updated_at" example:"2025-08-11T10:00:00Z"`
+}
+
+// Weight is a body weight measurement; a user has at most one per date
+type Weight struct {
+	Date      string    `json:"date" example:"2025-08-11"`
+	WeightKg  float64   `json:"weight_kg" example:"72.5"`
+	CreatedAt time.Time `json:"created_at" example:"2025-08-11T07:00:00Z"`
+}
+
+// Recipe is a saved combination of ingredients with their fetched nutrients
+type Recipe struct {
+	Name        string               `json:"name"`
+	Ingredients []string             `json:"ingredients"`
+	Servings    float64              `json:"servings"`
+	Nutrients   nutritionix.Response `json:"nutrients"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// Goal holds the daily nutrition targets of a user
+type Goal struct {
+	Calories    float64 `json:"calories"`
+	Protein     float64 `json:"protein_g"`
+	Carbs       float64 `json:"carbs_g"`
+	Fat         float64 `json:"fat_g"`
+	Maintenance float64 `json:"maintenance_calories,omitempty"`
+}
+
+// MealUncategorized is the meal of entries logged without a meal category
+const MealUncategorized = "uncategorized"
+
+var (
+	// ErrDuplicateClientID is returned when the user already uses a client_id
+	ErrDuplicateClientID = errors.New("client_id already exists")
+	// ErrEntryNotFound is returned when a stored entry does not exist
+	ErrEntryNotFound = errors.New("entry not found")
+	// ErrStoreNotEmpty is returned when the ID counter is reset while entries exist
+	ErrStoreNotEmpty = errors.New("store is not empty")
+	// ErrRecipeExists is returned when the user already has a recipe of that name
+	ErrRecipeExists = errors.New("recipe already exists")
+)
+
+// UpdateFunc receives the current entries, which it must not modify, and
+// returns the entries to save and the IDs to delete; an error aborts the update
+type UpdateFunc func(current map[int]Entry) (save []Entry, remove []int, err error)
+
+// Store keeps entries by ID; IDs are assigned by Create and never reused. It
+// also keeps the goals, body weights and recipes of each user
+type Store interface {
+	// Get returns an entry by ID
+	Get(id int) (Entry, bool)
+	// List returns a copy of all entries ordered by ID
+	List() []Entry
+	// Create assigns IDs and stores all entries or none of them
+	Create(entries []Entry) ([]Entry, error)
+	// Delete removes an entry and releases its client_id
+	Delete(id int) error
+	// Update applies fn atomically with respect to other changes
+	Update(fn UpdateFunc) error
+	// Count returns the number of entries
+	Count() int
+	// ClientIDExists reports whether an entry of userID already uses the client_id
+	ClientIDExists(userID, clientID string) bool
+	// ResetIDs restarts the ID sequence at 1; only allowed while the store is empty
+	ResetIDs() error
+	// SaveGoal stores the goal of userID, replacing any previous one
+	SaveGoal(userID string, g Goal) error
+	// GetGoal returns the goal of userID
+	GetGoal(userID string) (Goal, bool, error)
+	// SaveWeight stores a weight of userID, replacing any of the same date
+	SaveWeight(userID string, w Weight) error
+	// Weights returns the weights of userID ordered by date
+	Weights(userID string) ([]Weight, error)
+	// CreateRecipe stores a recipe of userID under key, a normalized name;
+	// ErrRecipeExists is returned when the key is taken
+	CreateRecipe(userID, key string, r Recipe) error
+	// GetRecipe returns the recipe of userID stored under key
+	GetRecipe(userID, key string) (Recipe, bool, error)
+	// Recipes returns the recipes of userID ordered by name
+	Recipes(userID string) ([]Recipe, error)
+	// Ping checks that the underlying storage is reachable
+	Ping(ctx context.Context) error
+	// Close releases the underlying storage
+	Close() error
+}